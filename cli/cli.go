@@ -0,0 +1,197 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/emon51/rental-scraper/config"
+	"github.com/emon51/rental-scraper/utils"
+)
+
+// Exit codes returned by Run
+const (
+	ExitOK      = 0 // Command completed successfully
+	ExitFailure = 1 // Scraping, database or file operation failed
+	ExitUsage   = 2 // Unknown command or invalid flags
+	ExitConfig  = 3 // Configuration could not be loaded or is invalid
+)
+
+// ProgramName is used in usage messages
+const ProgramName = "rental-scraper"
+
+// defaultCommand runs when no subcommand is given, keeping `go run main.go` working
+const defaultCommand = "run"
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+var commands = []command{
+	{name: "run", summary: "Scrape, save to CSV and PostgreSQL, and print insights (default)", run: runCommand},
+	{name: "scrape", summary: "Scrape listings and write them to the selected sinks", run: scrapeCommand},
+	{name: "export", summary: "Export stored listings from PostgreSQL to CSV or JSON", run: exportCommand},
+	{name: "report", summary: "Print market insights for listings stored in PostgreSQL", run: reportCommand},
+	{name: "migrate", summary: "Create or update the database schema", run: migrateCommand},
+	{name: "config", summary: "Inspect the effective configuration (print, validate)", run: configCommand},
+	{name: "fixtures", summary: "Serve saved pages for offline development (serve)", run: fixturesCommand},
+}
+
+// Run dispatches to a subcommand and returns the process exit code
+func Run(args []string) int {
+	name := defaultCommand
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	} else if len(args) > 0 && isHelpFlag(args[0]) {
+		printUsage(os.Stdout)
+		return ExitOK
+	}
+
+	if name == "help" {
+		printUsage(os.Stdout)
+		return ExitOK
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd.run(args)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+	printUsage(os.Stderr)
+	return ExitUsage
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags]\n\nCommands:\n", ProgramName)
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-9s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprint(w, exitCodeHelp)
+	fmt.Fprintf(w, "\nRun '%s <command> -h' for command flags.\n", ProgramName)
+}
+
+const exitCodeHelp = `
+Exit codes:
+  0  success
+  1  runtime failure (scraping, database or file errors)
+  2  invalid command line usage
+  3  invalid configuration
+`
+
+func isHelpFlag(arg string) bool {
+	return arg == "-h" || arg == "-help" || arg == "--help"
+}
+
+// commonFlags are accepted by every subcommand
+type commonFlags struct {
+	configPath string
+	logFile    string
+	overrides  *config.Overrides // Set only by commands that scrape
+}
+
+// newFlagSet creates a flag set with the common flags and a usage message
+func newFlagSet(name, usage string) (*flag.FlagSet, *commonFlags) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	common := &commonFlags{}
+
+	fs.StringVar(&common.configPath, "config", "", "path to a YAML, JSON or TOML config file (default $"+config.ConfigPathEnv+")")
+	fs.StringVar(&common.logFile, "log", "scraper.log", "path of the log file")
+
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s\n\nFlags:\n", ProgramName, usage)
+		fs.PrintDefaults()
+		fmt.Fprint(fs.Output(), exitCodeHelp)
+	}
+
+	return fs, common
+}
+
+// parseFlags parses args and returns the exit code to use when parsing stops the command
+func parseFlags(fs *flag.FlagSet, args []string) (int, bool) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK, false
+		}
+		return ExitUsage, false
+	}
+	return ExitOK, true
+}
+
+// addScrapeFlags registers the per-run location and paging overrides
+func addScrapeFlags(fs *flag.FlagSet, common *commonFlags) {
+	common.overrides = &config.Overrides{}
+	fs.Var((*listFlag)(&common.overrides.Locations), "location", "comma-separated locations to scrape, by slug or city name; unknown slugs are scraped ad hoc (repeatable)")
+	fs.IntVar(&common.overrides.PagesToScrape, "pages", -1, "pages to scrape per location, 0 until results run out; -1 keeps the config value")
+	fs.IntVar(&common.overrides.ListingsPerPage, "per-page", 0, "listings to take from each page (default from config)")
+	fs.IntVar(&common.overrides.MaxListings, "max-listings", 0, "stop each location after this many listings (default from config)")
+	fs.StringVar(&common.overrides.FixturesDir, "fixtures", "", "scrape saved pages from this directory instead of the live site")
+	fs.StringVar(&common.overrides.RecordPath, "record", "", "record pages, evaluation results and network responses to this archive (.jsonl.gz)")
+	fs.StringVar(&common.overrides.ReplayPath, "replay", "", "replay a recorded archive instead of the live site")
+	fs.StringVar(&common.overrides.DebugDir, "debug-dir", "", "save a screenshot and the HTML of pages that yield too few listings or no description")
+	fs.StringVar(&common.overrides.ChromeWS, "chrome-ws", "", "DevTools URL of a running Chrome to use instead of launching one (e.g. ws://host:9222)")
+}
+
+// listFlag collects comma-separated values across repeated flags
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// loadConfig resolves and validates the configuration, applying command line overrides
+func loadConfig(common *commonFlags) (*config.Config, int) {
+	path := config.ResolvePath(common.configPath)
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration:\n%v\n", err)
+		return nil, ExitConfig
+	}
+
+	if common.overrides != nil {
+		cfg.ApplyOverrides(*common.overrides)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Refusing to start: %v\n", err)
+		return nil, ExitConfig
+	}
+
+	return cfg, ExitOK
+}
+
+// setup loads the configuration and opens the logger shared by every command
+func setup(common *commonFlags) (*config.Config, *utils.Logger, int) {
+	cfg, code := loadConfig(common)
+	if code != ExitOK {
+		return nil, nil, code
+	}
+
+	logger, err := utils.NewLogger(common.logFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
+		return nil, nil, ExitFailure
+	}
+
+	if path := config.ResolvePath(common.configPath); path != "" {
+		logger.Info(fmt.Sprintf("Configuration loaded from %s", path))
+	}
+
+	return cfg, logger, ExitOK
+}
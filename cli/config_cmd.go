@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/emon51/rental-scraper/config"
+)
+
+// configCommand prints or validates the effective configuration
+func configCommand(args []string) int {
+	fs, common := newFlagSet("config", "config <print|validate> [flags]")
+	if len(args) == 0 || isHelpFlag(args[0]) {
+		fs.Usage()
+		if len(args) == 0 {
+			return ExitUsage
+		}
+		return ExitOK
+	}
+
+	action, args := args[0], args[1:]
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "Unexpected arguments: %v\n", fs.Args())
+		return ExitUsage
+	}
+
+	switch action {
+	case "print":
+		// Print even an invalid config so it can be inspected
+		cfg, err := config.Load(config.ResolvePath(common.configPath))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load configuration:\n%v\n", err)
+			return ExitConfig
+		}
+		if err := cfg.Dump(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ExitFailure
+		}
+		return ExitOK
+	case "validate":
+		if _, code := loadConfig(common); code != ExitOK {
+			return code
+		}
+		fmt.Println("Configuration is valid")
+		return ExitOK
+	default:
+		fmt.Fprintf(fs.Output(), "Unknown config action %q (use print or validate)\n", action)
+		return ExitUsage
+	}
+}
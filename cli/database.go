@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/emon51/rental-scraper/config"
+	"github.com/emon51/rental-scraper/models"
+	"github.com/emon51/rental-scraper/services"
+	"github.com/emon51/rental-scraper/storage"
+	"github.com/emon51/rental-scraper/utils"
+)
+
+// exportCommand copies stored listings from PostgreSQL into a CSV or JSON file
+func exportCommand(args []string) int {
+	fs, common := newFlagSet("export", "export [flags]")
+	format := fs.String("format", "", "output format: csv or json (default from -out extension, else csv)")
+	out := fs.String("out", "", "output file (default listings.<format>)")
+
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "Unexpected arguments: %v\n", fs.Args())
+		return ExitUsage
+	}
+
+	resolved, err := resolveExportFormat(*format, *out)
+	if err != nil {
+		fmt.Fprintln(fs.Output(), err)
+		return ExitUsage
+	}
+	if *out == "" {
+		*out = "listings." + resolved
+	}
+
+	cfg, logger, code := setup(common)
+	if code != ExitOK {
+		return code
+	}
+	defer logger.Close()
+
+	listings, err := loadStoredListings(cfg, logger)
+	if err != nil {
+		logger.Error("Failed to read listings", err)
+		return ExitFailure
+	}
+
+	var writeErr error
+	switch resolved {
+	case "json":
+		writeErr = storage.NewJSONWriter(*out).WriteListings(listings)
+	default:
+		writeErr = storage.NewCSVWriter(*out).WriteListings(listings)
+	}
+	if writeErr != nil {
+		logger.Error(fmt.Sprintf("Failed to write %s", *out), writeErr)
+		return ExitFailure
+	}
+
+	logger.Success(fmt.Sprintf("Exported %d listings to %s", len(listings), *out))
+	return ExitOK
+}
+
+// resolveExportFormat picks the format from the flag or the output file extension
+func resolveExportFormat(format, out string) (string, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(out)) {
+		case ".json":
+			return "json", nil
+		default:
+			return "csv", nil
+		}
+	}
+
+	format = strings.ToLower(format)
+	if format != "csv" && format != "json" {
+		return "", fmt.Errorf("unsupported format %q (use csv or json)", format)
+	}
+	return format, nil
+}
+
+// reportCommand generates insights from stored data without scraping
+func reportCommand(args []string) int {
+	fs, common := newFlagSet("report", "report [flags]")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "Unexpected arguments: %v\n", fs.Args())
+		return ExitUsage
+	}
+
+	cfg, logger, code := setup(common)
+	if code != ExitOK {
+		return code
+	}
+	defer logger.Close()
+
+	listings, err := loadStoredListings(cfg, logger)
+	if err != nil {
+		logger.Error("Failed to read listings", err)
+		return ExitFailure
+	}
+
+	insightGen := services.NewInsightGenerator()
+	insightGen.PrintReport(insightGen.Generate(listings))
+	logger.Success("Insights generated successfully")
+
+	return ExitOK
+}
+
+// migrateCommand creates the listings table and its indexes
+func migrateCommand(args []string) int {
+	fs, common := newFlagSet("migrate", "migrate [flags]")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "Unexpected arguments: %v\n", fs.Args())
+		return ExitUsage
+	}
+
+	cfg, logger, code := setup(common)
+	if code != ExitOK {
+		return code
+	}
+	defer logger.Close()
+
+	pgWriter, err := services.OpenDatabase(cfg)
+	if err != nil {
+		logger.Error("Failed to connect to PostgreSQL", err)
+		return ExitFailure
+	}
+	defer pgWriter.Close()
+
+	if err := pgWriter.CreateTable(); err != nil {
+		logger.Error("Migration failed", err)
+		return ExitFailure
+	}
+
+	logger.Success("Database schema is up to date")
+	return ExitOK
+}
+
+// loadStoredListings reads every listing saved in PostgreSQL
+func loadStoredListings(cfg *config.Config, logger *utils.Logger) ([]models.Listing, error) {
+	logger.Info("Connecting to PostgreSQL")
+
+	pgWriter, err := services.OpenDatabase(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("connection failed: %w", err)
+	}
+	defer pgWriter.Close()
+
+	listings, err := pgWriter.GetAllListings()
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("Loaded %d listings from PostgreSQL", len(listings)))
+	return listings, nil
+}
@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/emon51/rental-scraper/config"
+	"github.com/emon51/rental-scraper/scraper"
+	"github.com/emon51/rental-scraper/utils"
+)
+
+// fetchSession bundles the Fetcher for a run with the context it must be used with
+type fetchSession struct {
+	fetcher scraper.Fetcher
+	ctx     context.Context
+	closers []func()
+}
+
+// Close releases everything the session opened, newest first
+func (s *fetchSession) Close() {
+	for i := len(s.closers) - 1; i >= 0; i-- {
+		s.closers[i]()
+	}
+}
+
+// openFetchSession builds the Fetcher for the configured mode: replaying an
+// archive, or driving Chrome against the live site or fixtures, optionally recording
+func openFetchSession(cfg *config.Config, logger *utils.Logger) (*fetchSession, error) {
+	session := &fetchSession{}
+
+	if cfg.Session.Replay != "" {
+		archive, err := scraper.LoadArchive(cfg.Session.Replay)
+		if err != nil {
+			return nil, err
+		}
+		// Resolve dates as the recorded run did, so its search URLs are asked for again
+		cfg.Session.RunDate = archive.RunDate()
+		logger.Info(fmt.Sprintf("Replaying recorded session from %s (run date %s)",
+			cfg.Session.Replay, cfg.Session.RunDate.Format(time.DateOnly)))
+
+		// Nothing goes over the network, so there is no one to be polite to
+		cfg.RateLimit.RequestsPerSecond = 0
+		cfg.Robots.Enabled = false
+
+		session.fetcher = scraper.NewReplayFetcher(archive)
+		session.startRun(cfg)
+		return session, nil
+	}
+
+	// Pin the run date, so searches started after midnight match the recording
+	cfg.Session.RunDate = time.Now()
+
+	pool, err := utils.NewBrowserPool(cfg)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info(fmt.Sprintf("Browser ready: %s", pool))
+	session.closers = append(session.closers, func() {
+		if err := pool.Close(); err != nil {
+			logger.Error("Failed to shut down browser", err)
+		}
+	})
+	session.fetcher = scraper.NewChromeFetcher(pool)
+
+	if cfg.Fixtures.Enabled() {
+		baseURL := cfg.Fixtures.URL
+		if cfg.Fixtures.Dir != "" {
+			server, err := scraper.StartFixtureServer(cfg.Fixtures.Dir, "")
+			if err != nil {
+				session.Close()
+				return nil, err
+			}
+			session.closers = append(session.closers, func() { server.Close() })
+			baseURL = server.URL()
+			logger.Info(fmt.Sprintf("Serving fixtures from %s at %s", cfg.Fixtures.Dir, baseURL))
+		}
+
+		fixtureFetcher, err := scraper.NewFixtureFetcher(session.fetcher, baseURL)
+		if err != nil {
+			session.Close()
+			return nil, err
+		}
+		session.fetcher = fixtureFetcher
+
+		// Fixture URLs stand in for the live site, whose robots.txt does not apply
+		cfg.Robots.Enabled = false
+		logger.Info(fmt.Sprintf("Offline mode: loading pages from %s", baseURL))
+	}
+
+	if cfg.Session.Record != "" {
+		archive, err := scraper.CreateArchive(cfg.Session.Record, cfg.Session.RunDate)
+		if err != nil {
+			session.Close()
+			return nil, err
+		}
+		session.closers = append(session.closers, func() {
+			if err := archive.Close(); err != nil {
+				logger.Error("Failed to finish session archive", err)
+				return
+			}
+			logger.Success(fmt.Sprintf("Session recorded to %s", cfg.Session.Record))
+		})
+		session.fetcher = scraper.NewRecordingFetcher(session.fetcher, archive)
+		logger.Info(fmt.Sprintf("Recording session to %s", cfg.Session.Record))
+	}
+
+	session.startRun(cfg)
+	return session, nil
+}
+
+// startRun creates the context bounding the whole run with timeouts.run. An
+// interrupt cancels it, so the pipeline unwinds and the browser is shut down cleanly.
+func (s *fetchSession) startRun(cfg *config.Config) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	ctx, cancel := scraper.WithBudget(ctx, scraper.BudgetRun, cfg.Timeouts.Run, "")
+	s.ctx = ctx
+	s.closers = append(s.closers, stop, cancel)
+}
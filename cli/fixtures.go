@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/emon51/rental-scraper/scraper"
+)
+
+// fixturesCommand serves a fixtures directory so pages can be opened in a
+// browser or shared by several scraper runs through fixtures.url
+func fixturesCommand(args []string) int {
+	fs, _ := newFlagSet("fixtures", "fixtures serve -dir <directory> [flags]")
+	dir := fs.String("dir", "fixtures", "directory holding saved pages")
+	addr := fs.String("addr", "127.0.0.1:8089", "address to listen on")
+
+	if len(args) == 0 || isHelpFlag(args[0]) {
+		fs.Usage()
+		if len(args) == 0 {
+			return ExitUsage
+		}
+		return ExitOK
+	}
+
+	action, args := args[0], args[1:]
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if action != "serve" || fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "Unknown fixtures action %q (use serve)\n", action)
+		return ExitUsage
+	}
+
+	server, err := scraper.StartFixtureServer(*dir, *addr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return ExitFailure
+	}
+	defer server.Close()
+
+	fmt.Printf("Serving %s at %s (Ctrl+C to stop)\n", *dir, server.URL())
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	<-stop
+
+	return ExitOK
+}
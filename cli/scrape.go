@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/emon51/rental-scraper/services"
+)
+
+// runCommand executes the full pipeline with the default sinks
+func runCommand(args []string) int {
+	fs, common := newFlagSet("run", "run [flags]")
+	addScrapeFlags(fs, common)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "Unexpected arguments: %v\n", fs.Args())
+		return ExitUsage
+	}
+
+	return executePipeline(common, services.DefaultSinks())
+}
+
+// scrapeCommand scrapes and writes only to the sinks selected by flags
+func scrapeCommand(args []string) int {
+	fs, common := newFlagSet("scrape", "scrape [flags]")
+	addScrapeFlags(fs, common)
+	sinks := services.Sinks{}
+	fs.StringVar(&sinks.CSVPath, "csv", "listings.csv", "write listings to this CSV file (empty to skip)")
+	fs.StringVar(&sinks.JSONPath, "json", "", "write listings to this JSON file (empty to skip)")
+	fs.BoolVar(&sinks.Database, "db", false, "insert listings into PostgreSQL")
+	fs.BoolVar(&sinks.Insights, "insights", false, "print the market insights report")
+
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "Unexpected arguments: %v\n", fs.Args())
+		return ExitUsage
+	}
+
+	return executePipeline(common, sinks)
+}
+
+func executePipeline(common *commonFlags, sinks services.Sinks) int {
+	startTime := time.Now()
+
+	cfg, logger, code := setup(common)
+	if code != ExitOK {
+		return code
+	}
+	defer logger.Close()
+
+	fmt.Println("Airbnb Rental Scraper Starting...")
+	logger.Info("Scraper application started")
+
+	session, err := openFetchSession(cfg, logger)
+	if err != nil {
+		logger.Error("Failed to prepare page fetcher", err)
+		return ExitFailure
+	}
+	defer session.Close()
+
+	// Create and execute pipeline
+	pipeline := services.NewPipeline(cfg, logger, session.fetcher).WithSinks(sinks)
+	if err := pipeline.Execute(session.ctx); err != nil {
+		logger.Error("Pipeline execution failed", err)
+		return ExitFailure
+	}
+
+	duration := time.Since(startTime)
+	logger.LogScrapingSession(0, duration)
+	fmt.Printf("\n✓ Scraping Complete! (Duration: %v)\n", duration)
+
+	return ExitOK
+}
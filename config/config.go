@@ -1,34 +1,347 @@
 package config
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
 type Config struct {
-	BaseURL           string
-	Locations         []LocationConfig
-	ListingsPerPage   int
-	PagesToScrape     int
-	PageTimeout       int
-	RequestDelay      int
-	Headless          bool
-	MaxConcurrent     int
-	DescriptionConfig DescriptionFetchConfig
-	DBConfig          DatabaseConfig
+	BaseURL           string                 `yaml:"base_url" toml:"base_url"`
+	Locations         []LocationConfig       `yaml:"locations" toml:"locations"`
+	ListingsPerPage   int                    `yaml:"listings_per_page" toml:"listings_per_page"`
+	PagesToScrape     int                    `yaml:"pages_to_scrape" toml:"pages_to_scrape"` // 0 = follow next-page links until results run out; a failed page ends the location
+	MaxListings       int                    `yaml:"max_listings" toml:"max_listings"`       // Stop a location at this many listings; 0 = no limit
+	Headless          bool                   `yaml:"headless" toml:"headless"`
+	MaxConcurrent     int                    `yaml:"max_concurrent" toml:"max_concurrent"`
+	Timeouts          TimeoutsConfig         `yaml:"timeouts" toml:"timeouts"`
+	Retry             RetryConfig            `yaml:"retry" toml:"retry"`
+	RateLimit         RateLimitConfig        `yaml:"rate_limit" toml:"rate_limit"`
+	Debug             DebugConfig            `yaml:"debug" toml:"debug"`
+	Blocking          BlockingConfig         `yaml:"blocking" toml:"blocking"`
+	DescriptionConfig DescriptionFetchConfig `yaml:"description" toml:"description"`
+	DBConfig          DatabaseConfig         `yaml:"database" toml:"database"`
+	Fixtures          FixtureConfig          `yaml:"fixtures" toml:"fixtures"`
+	Session           SessionConfig          `yaml:"session" toml:"session"`
+	Waits             WaitsConfig            `yaml:"waits" toml:"waits"`
+	Browser           BrowserConfig          `yaml:"browser" toml:"browser"`
+	Profiles          []ProfileConfig        `yaml:"profiles" toml:"profiles"`
+	Proxies           []ProxyConfig          `yaml:"proxies" toml:"proxies"`
+	Proxy             ProxyPolicyConfig      `yaml:"proxy" toml:"proxy"`
+	Robots            RobotsConfig           `yaml:"robots" toml:"robots"`
+	Search            SearchConfig           `yaml:"search" toml:"search"`
+	Sweep             SweepConfig            `yaml:"sweep" toml:"sweep"`
 }
 
 type LocationConfig struct {
-	Slug        string
-	DisplayName string
+	Slug        string       `yaml:"slug" toml:"slug"`
+	DisplayName string       `yaml:"display_name" toml:"display_name"`
+	Profile     string       `yaml:"profile" toml:"profile"` // Browser profile pinned to this location; empty uses browser.profile
+	Proxy       string       `yaml:"proxy" toml:"proxy"`     // Proxy name or region for this location; empty draws from every proxy
+	Search      SearchConfig `yaml:"search" toml:"search"`   // Overrides the fields it sets in the top-level search
+}
+
+// SearchConfig narrows a location's search. Zero values leave a filter off.
+type SearchConfig struct {
+	CheckIn      string `yaml:"check_in" toml:"check_in"`   // YYYY-MM-DD, or days or weeks from the run day such as +30d or +2w
+	CheckOut     string `yaml:"check_out" toml:"check_out"` // Same forms as check_in
+	Adults       int    `yaml:"adults" toml:"adults"`
+	Children     int    `yaml:"children" toml:"children"`
+	PriceMin     int    `yaml:"price_min" toml:"price_min"` // Nightly price bounds in the site's currency
+	PriceMax     int    `yaml:"price_max" toml:"price_max"`
+	RoomType     string `yaml:"room_type" toml:"room_type"`         // One of RoomTypes
+	PropertyType string `yaml:"property_type" toml:"property_type"` // One of PropertyTypes
+}
+
+// SweepConfig expands every location into one search per stay window,
+// replacing the check_in and check_out of its search
+type SweepConfig struct {
+	Windows int    `yaml:"windows" toml:"windows"` // Number of stay windows; 0 turns the sweep off
+	Start   string `yaml:"start" toml:"start"`     // First check-in, in the forms check_in accepts
+	Weekday string `yaml:"weekday" toml:"weekday"` // Move the first check-in forward to this day, e.g. friday; empty keeps it
+	Nights  int    `yaml:"nights" toml:"nights"`   // Length of each stay
+	Every   int    `yaml:"every" toml:"every"`     // Days from one check-in to the next; 0 means back-to-back stays
+}
+
+// Enabled reports whether the sweep is on
+func (s SweepConfig) Enabled() bool {
+	return s.Windows > 0
+}
+
+// FirstCheckIn resolves start against today and moves it to weekday
+func (s SweepConfig) FirstCheckIn(today time.Time) (time.Time, error) {
+	first, err := ResolveDate(s.Start, today)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if s.Weekday == "" {
+		return first, nil
+	}
+
+	weekday, ok := parseWeekday(s.Weekday)
+	if !ok {
+		return time.Time{}, fmt.Errorf("unknown weekday %q", s.Weekday)
+	}
+	return first.AddDate(0, 0, (int(weekday)-int(first.Weekday())+7)%7), nil
+}
+
+func parseWeekday(name string) (time.Weekday, bool) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(name, day.String()) {
+			return day, true
+		}
+	}
+	return 0, false
+}
+
+// RoomTypes are the values search.room_type accepts
+var RoomTypes = []string{"entire_home", "private_room", "shared_room", "hotel_room"}
+
+// PropertyTypes are the values search.property_type accepts
+var PropertyTypes = []string{"house", "apartment", "guesthouse", "hotel"}
+
+// ResolveDate turns a check_in or check_out value into a date, counting
+// relative values from today. An empty value gives the zero time.
+func ResolveDate(value string, today time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if rest, ok := strings.CutPrefix(value, "+"); ok && len(rest) > 1 {
+		n, err := strconv.Atoi(rest[:len(rest)-1])
+		if err == nil && n >= 0 {
+			day := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+			switch rest[len(rest)-1] {
+			case 'd':
+				return day.AddDate(0, 0, n), nil
+			case 'w':
+				return day.AddDate(0, 0, 7*n), nil
+			}
+		}
+	}
+
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither a YYYY-MM-DD date nor a relative date like +30d or +2w", value)
+	}
+	return date, nil
 }
 
 type DescriptionFetchConfig struct {
-	MaxConcurrent int // Concurrent description fetches per location
-	Timeout       int // Timeout for each description fetch in seconds
+	MaxConcurrent int `yaml:"max_concurrent" toml:"max_concurrent"` // Concurrent description fetches per location
+}
+
+// TimeoutsConfig holds the time budgets, in seconds, for each level of a run.
+// Each budget is nested in the one above it; 0 means no limit for run and location.
+type TimeoutsConfig struct {
+	Run        int `yaml:"run" toml:"run"`                 // Whole scrape
+	Location   int `yaml:"location" toml:"location"`       // One location, search pages and descriptions together
+	SearchPage int `yaml:"search_page" toml:"search_page"` // Loading and extracting one search page
+	DetailPage int `yaml:"detail_page" toml:"detail_page"` // Loading one listing page for its description
+}
+
+// RetryConfig controls how failed search and detail pages are retried
+type RetryConfig struct {
+	MaxAttempts    int     `yaml:"max_attempts" toml:"max_attempts"`       // Tries per page, including the first; 1 disables retries
+	InitialBackoff int     `yaml:"initial_backoff" toml:"initial_backoff"` // Seconds before the first retry, doubled for each further one
+	MaxBackoff     int     `yaml:"max_backoff" toml:"max_backoff"`         // Upper bound on the wait between tries, in seconds
+	Jitter         float64 `yaml:"jitter" toml:"jitter"`                   // Randomize each wait by up to this fraction (0-1)
+}
+
+// RateLimitConfig caps the navigation rate per host across the whole run
+type RateLimitConfig struct {
+	RequestsPerSecond float64 `yaml:"requests_per_second" toml:"requests_per_second"` // Sustained rate per host; 0 disables limiting
+	Burst             int     `yaml:"burst" toml:"burst"`                             // Requests allowed back to back before the rate applies
+}
+
+// BlockingConfig lists requests the browser fails instead of loading
+type BlockingConfig struct {
+	ResourceTypes []string `yaml:"resource_types" toml:"resource_types"` // DevTools resource types, e.g. image, font, media
+	URLPatterns   []string `yaml:"url_patterns" toml:"url_patterns"`     // URL wildcards ('*' and '?'), e.g. *google-analytics.com*
+}
+
+// DebugConfig controls the captures kept for pages that yield too little
+type DebugConfig struct {
+	Dir string `yaml:"dir" toml:"dir"` // Save a screenshot and the HTML of such pages under this directory, one subdirectory per run; empty disables
+}
+
+// BrowserConfig selects the browser and controls its pool of tabs
+type BrowserConfig struct {
+	RemoteURL    string `yaml:"remote_url" toml:"remote_url"`       // DevTools endpoint of a running Chrome (ws://host:9222); empty launches one locally
+	MaxTabs      int    `yaml:"max_tabs" toml:"max_tabs"`           // Open tabs at once; 0 fits max_concurrent locations with their description workers
+	RecycleAfter int    `yaml:"recycle_after" toml:"recycle_after"` // Replace a tab after this many navigations; 0 never
+	Profile      string `yaml:"profile" toml:"profile"`             // Profile for locations without one: a name, "rotate", or empty for the default
+}
+
+// ProxyConfig is one egress proxy. Chrome supports credentials for HTTP(S) proxies only.
+type ProxyConfig struct {
+	Name     string `yaml:"name" toml:"name"`
+	URL      string `yaml:"url" toml:"url"`       // http://host:port, https://host:port or socks5://host:port
+	Region   string `yaml:"region" toml:"region"` // Groups proxies so locations can ask for any proxy in a region
+	Username string `yaml:"username" toml:"username"`
+	Password Secret `yaml:"password" toml:"password"`
+}
+
+// ProxyPolicyConfig controls proxy health tracking
+type ProxyPolicyConfig struct {
+	BanAfter    int `yaml:"ban_after" toml:"ban_after"`       // Consecutive failures (blocks, timeouts, connection errors) before a proxy is benched
+	BanDuration int `yaml:"ban_duration" toml:"ban_duration"` // Seconds a benched proxy is left out of rotation
+}
+
+// RobotsConfig controls the robots.txt checks made before every navigation
+type RobotsConfig struct {
+	Enabled   bool     `yaml:"enabled" toml:"enabled"`
+	UserAgent string   `yaml:"user_agent" toml:"user_agent"` // Product token matched against User-agent lines
+	Allow     []string `yaml:"allow" toml:"allow"`           // Path patterns ('*' and '$' as in robots.txt) fetched even when disallowed
+}
+
+// DefaultUserAgent is sent by tabs without a profile, and by profiles that set none
+const DefaultUserAgent = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+// ProfileRotate assigns the profiles to locations in turn, in location order
+const ProfileRotate = "rotate"
+
+// ProfileConfig is a named browser fingerprint applied to a tab
+type ProfileConfig struct {
+	Name           string `yaml:"name" toml:"name"`
+	UserAgent      string `yaml:"user_agent" toml:"user_agent"`           // Defaults to DefaultUserAgent
+	ViewportWidth  int    `yaml:"viewport_width" toml:"viewport_width"`   // 0 keeps the window size
+	ViewportHeight int    `yaml:"viewport_height" toml:"viewport_height"` // 0 keeps the window size
+	Locale         string `yaml:"locale" toml:"locale"`                   // ICU locale such as en-US
+	Timezone       string `yaml:"timezone" toml:"timezone"`               // IANA zone such as Asia/Tokyo
+	AcceptLanguage string `yaml:"accept_language" toml:"accept_language"` // Accept-Language header such as "ja-JP,ja;q=0.9"
+}
+
+// Profile looks up a profile by name
+func (c *Config) Profile(name string) (ProfileConfig, bool) {
+	for _, p := range c.Profiles {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return ProfileConfig{}, false
+}
+
+// LocationProfile resolves the profile for Locations[index]: its pinned profile,
+// else browser.profile, rotating through Profiles by index for "rotate".
+// It reports false when the location uses the default browser settings.
+func (c *Config) LocationProfile(index int) (ProfileConfig, bool) {
+	name := c.Locations[index].Profile
+	if name == "" {
+		name = c.Browser.Profile
+	}
+	if name == ProfileRotate {
+		if len(c.Profiles) == 0 {
+			return ProfileConfig{}, false
+		}
+		return c.Profiles[index%len(c.Profiles)], true
+	}
+	if name == "" {
+		return ProfileConfig{}, false
+	}
+	return c.Profile(name)
+}
+
+// LocationSearch returns the search for the location at index: the top-level
+// search with the fields the location sets replaced
+func (c *Config) LocationSearch(index int) SearchConfig {
+	search := c.Search
+	override := c.Locations[index].Search
+	if override.CheckIn != "" {
+		search.CheckIn = override.CheckIn
+	}
+	if override.CheckOut != "" {
+		search.CheckOut = override.CheckOut
+	}
+	if override.Adults != 0 {
+		search.Adults = override.Adults
+	}
+	if override.Children != 0 {
+		search.Children = override.Children
+	}
+	if override.PriceMin != 0 {
+		search.PriceMin = override.PriceMin
+	}
+	if override.PriceMax != 0 {
+		search.PriceMax = override.PriceMax
+	}
+	if override.RoomType != "" {
+		search.RoomType = override.RoomType
+	}
+	if override.PropertyType != "" {
+		search.PropertyType = override.PropertyType
+	}
+	return search
+}
+
+// EffectiveMaxTabs resolves MaxTabs, deriving it from the concurrency settings when unset
+func (b BrowserConfig) EffectiveMaxTabs(cfg *Config) int {
+	if b.MaxTabs > 0 {
+		return b.MaxTabs
+	}
+	return cfg.MaxConcurrent * (1 + cfg.DescriptionConfig.MaxConcurrent)
+}
+
+// Wait strategies for deciding when a page is ready to extract
+const (
+	WaitSelector    = "selector"     // Poll until enough elements match a selector
+	WaitNetworkIdle = "network_idle" // Poll until no new resources load for a short window
+	WaitSleep       = "sleep"        // Sleep for the whole timeout
+	WaitNone        = "none"         // No fallback
+)
+
+// WaitsConfig holds the readiness conditions for each page type
+type WaitsConfig struct {
+	Search      WaitConfig `yaml:"search" toml:"search"`
+	Description WaitConfig `yaml:"description" toml:"description"`
+}
+
+// WaitConfig describes how to wait for a page, and what to try if that times out
+type WaitConfig struct {
+	Strategy        string `yaml:"strategy" toml:"strategy"`                 // selector, network_idle or sleep
+	Selector        string `yaml:"selector" toml:"selector"`                 // Defaults to the listing card or description selector
+	MinCount        int    `yaml:"min_count" toml:"min_count"`               // Elements required; 0 means listings_per_page for search, 1 for descriptions
+	Timeout         int    `yaml:"timeout" toml:"timeout"`                   // Seconds before giving up on the strategy
+	Fallback        string `yaml:"fallback" toml:"fallback"`                 // Strategy tried after a timeout, or none
+	FallbackTimeout int    `yaml:"fallback_timeout" toml:"fallback_timeout"` // Seconds allowed for the fallback
+}
+
+// FixtureConfig switches the scraper to saved pages instead of the live site
+type FixtureConfig struct {
+	Dir string `yaml:"dir" toml:"dir"` // Serve pages from this directory on a local port
+	URL string `yaml:"url" toml:"url"` // Or load them from an already running fixture server
+}
+
+// Enabled reports whether pages come from fixtures
+func (f FixtureConfig) Enabled() bool {
+	return f.Dir != "" || f.URL != ""
+}
+
+// SessionConfig records a browser session to an archive or replays one
+type SessionConfig struct {
+	Record string `yaml:"record" toml:"record"` // Write every page, evaluation and response to this archive
+	Replay string `yaml:"replay" toml:"replay"` // Serve pages from this archive instead of a browser
+
+	// RunDate is the day relative dates resolve against: the recorded day on
+	// replay, otherwise the day the run started. Zero means today.
+	RunDate time.Time `yaml:"-" toml:"-"`
+}
+
+// Today returns the run date, or the current time when none is set
+func (c *Config) Today() time.Time {
+	if !c.Session.RunDate.IsZero() {
+		return c.Session.RunDate
+	}
+	return time.Now()
 }
 
 type DatabaseConfig struct {
-	Host     string
-	Port     int
-	User     string
-	Password string
-	DBName   string
+	Host     string `yaml:"host" toml:"host"`
+	Port     int    `yaml:"port" toml:"port"`
+	User     string `yaml:"user" toml:"user"`
+	Password Secret `yaml:"password" toml:"password"`
+	DBName   string `yaml:"dbname" toml:"dbname"`
 }
 
 func NewConfig() *Config {
@@ -36,13 +349,64 @@ func NewConfig() *Config {
 		BaseURL:         "https://www.airbnb.com/s/%s/homes",
 		ListingsPerPage: 5,
 		PagesToScrape:   2,
-		PageTimeout:     600,
-		RequestDelay:    2,
 		Headless:        true,
 		MaxConcurrent:   3,
 		DescriptionConfig: DescriptionFetchConfig{
 			MaxConcurrent: 3,
-			Timeout:       10,
+		},
+		Timeouts: TimeoutsConfig{
+			Location:   900,
+			SearchPage: 60,
+			DetailPage: 30,
+		},
+		Retry: RetryConfig{
+			MaxAttempts:    3,
+			InitialBackoff: 2,
+			MaxBackoff:     30,
+			Jitter:         0.3,
+		},
+		RateLimit: RateLimitConfig{
+			RequestsPerSecond: 0.5,
+			Burst:             1,
+		},
+		Blocking: BlockingConfig{
+			// Images stay loaded so debug screenshots show the page as a user sees it
+			ResourceTypes: []string{"font", "media"},
+			URLPatterns: []string{
+				"*google-analytics.com*",
+				"*googletagmanager.com*",
+				"*doubleclick.net*",
+				"*connect.facebook.net*",
+			},
+		},
+		Proxy: ProxyPolicyConfig{
+			BanAfter:    3,
+			BanDuration: 600,
+		},
+		Browser: BrowserConfig{
+			RecycleAfter: 50,
+		},
+		Sweep: SweepConfig{
+			Start:  "+1d",
+			Nights: 2,
+		},
+		Robots: RobotsConfig{
+			Enabled:   true,
+			UserAgent: "rental-scraper",
+		},
+		Waits: WaitsConfig{
+			Search: WaitConfig{
+				Strategy:        WaitSelector,
+				Timeout:         15,
+				Fallback:        WaitNetworkIdle,
+				FallbackTimeout: 10,
+			},
+			Description: WaitConfig{
+				Strategy:        WaitSelector,
+				Timeout:         6,
+				Fallback:        WaitNetworkIdle,
+				FallbackTimeout: 3,
+			},
 		},
 		Locations: []LocationConfig{
 			{Slug: "Kuala-Lumpur", DisplayName: "Kuala Lumpur, Malaysia"},
@@ -63,4 +427,4 @@ func NewConfig() *Config {
 			DBName:   "rental_scraper",
 		},
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is prepended to every environment variable that overrides a config field
+const EnvPrefix = "RSCRAPER_"
+
+// fileSuffix marks a variable whose value is the path of a file holding the real value
+const fileSuffix = "_FILE"
+
+// LookupFunc matches os.LookupEnv so the resolution can be driven from any source
+type LookupFunc func(key string) (string, bool)
+
+// applyEnv overrides config fields from RSCRAPER_* variables and their *_FILE variants.
+// Names follow the YAML keys, e.g. database.password -> RSCRAPER_DATABASE_PASSWORD.
+// Scalars are parsed directly; lists and nested structs take a YAML/JSON value.
+// A *_FILE variable wins over the plain variable, so mounted secrets take precedence.
+func (c *Config) applyEnv(lookup LookupFunc) error {
+	loadErr := &LoadError{Path: "environment"}
+	applyEnvToStruct(reflect.ValueOf(c).Elem(), strings.TrimSuffix(EnvPrefix, "_"), lookup, loadErr)
+
+	if len(loadErr.Problems) > 0 {
+		return loadErr
+	}
+	return nil
+}
+
+func applyEnvToStruct(v reflect.Value, prefix string, lookup LookupFunc, loadErr *LoadError) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if tag == "" || tag == "-" || !field.IsExported() {
+			continue
+		}
+
+		name := prefix + "_" + strings.ToUpper(tag)
+		fv := v.Field(i)
+
+		if raw, ok := lookup(name); ok {
+			if err := setFromString(fv, raw); err != nil {
+				loadErr.Problems = append(loadErr.Problems, fmt.Sprintf("%s: %v", name, err))
+			}
+		}
+
+		if path, ok := lookup(name + fileSuffix); ok {
+			raw, err := readSecretFile(path)
+			if err == nil {
+				err = setFromString(fv, raw)
+			}
+			if err != nil {
+				loadErr.Problems = append(loadErr.Problems, fmt.Sprintf("%s%s: %v", name, fileSuffix, err))
+			}
+		}
+
+		// Field-level variables refine a struct given as a whole
+		if fv.Kind() == reflect.Struct {
+			applyEnvToStruct(fv, name, lookup, loadErr)
+		}
+	}
+}
+
+// setFromString parses raw according to the field's kind
+func setFromString(fv reflect.Value, raw string) error {
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(raw)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		fv.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		fv.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		fv.SetBool(b)
+	default:
+		// Lists and nested structs are given as a YAML or JSON document
+		target := reflect.New(fv.Type())
+		target.Elem().Set(fv)
+		if err := yaml.Unmarshal([]byte(raw), target.Interface()); err != nil {
+			return fmt.Errorf("invalid value: %v", err)
+		}
+		fv.Set(target.Elem())
+	}
+	return nil
+}
+
+// readSecretFile returns the file contents without the trailing newline most secret stores add
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
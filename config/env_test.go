@@ -0,0 +1,205 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// lookupMap is a LookupFunc over a fixed set of variables
+func lookupMap(vars map[string]string) LookupFunc {
+	return func(key string) (string, bool) {
+		value, ok := vars[key]
+		return value, ok
+	}
+}
+
+// writeSecret writes content to a file in a test directory and returns its path
+func writeSecret(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestApplyEnv(t *testing.T) {
+	secret := writeSecret(t, "from-file\n")
+
+	tests := []struct {
+		name  string
+		vars  map[string]string
+		check func(t *testing.T, cfg *Config)
+	}{
+		{
+			name: "scalars",
+			vars: map[string]string{
+				"RSCRAPER_BASE_URL":                       "http://localhost:8089/s/%s/homes",
+				"RSCRAPER_LISTINGS_PER_PAGE":              " 12 ",
+				"RSCRAPER_HEADLESS":                       "false",
+				"RSCRAPER_RATE_LIMIT_REQUESTS_PER_SECOND": "0.5",
+				"RSCRAPER_DATABASE_PORT":                  "6543",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.BaseURL != "http://localhost:8089/s/%s/homes" || cfg.ListingsPerPage != 12 || cfg.Headless ||
+					cfg.RateLimit.RequestsPerSecond != 0.5 || cfg.DBConfig.Port != 6543 {
+					t.Errorf("got base_url %q, listings_per_page %d, headless %v, requests_per_second %g, database.port %d",
+						cfg.BaseURL, cfg.ListingsPerPage, cfg.Headless, cfg.RateLimit.RequestsPerSecond, cfg.DBConfig.Port)
+				}
+			},
+		},
+		{
+			name: "list as YAML",
+			vars: map[string]string{
+				"RSCRAPER_LOCATIONS":    "[{slug: Seoul, display_name: 'Seoul, South Korea'}]",
+				"RSCRAPER_ROBOTS_ALLOW": `["/s/", "/rooms/"]`,
+			},
+			check: func(t *testing.T, cfg *Config) {
+				want := []LocationConfig{{Slug: "Seoul", DisplayName: "Seoul, South Korea"}}
+				if !reflect.DeepEqual(cfg.Locations, want) {
+					t.Errorf("locations = %+v, want %+v", cfg.Locations, want)
+				}
+				if !reflect.DeepEqual(cfg.Robots.Allow, []string{"/s/", "/rooms/"}) {
+					t.Errorf("robots.allow = %q", cfg.Robots.Allow)
+				}
+			},
+		},
+		{
+			name: "struct as YAML refined by a field variable",
+			vars: map[string]string{
+				"RSCRAPER_TIMEOUTS":     "{run: 100, location: 50}",
+				"RSCRAPER_TIMEOUTS_RUN": "200",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Timeouts.Run != 200 || cfg.Timeouts.Location != 50 {
+					t.Errorf("timeouts = %+v, want run 200 and location 50", cfg.Timeouts)
+				}
+				if cfg.Timeouts.SearchPage != NewConfig().Timeouts.SearchPage {
+					t.Errorf("timeouts.search_page = %d, want the default kept", cfg.Timeouts.SearchPage)
+				}
+			},
+		},
+		{
+			name: "file wins over plain variable",
+			vars: map[string]string{
+				"RSCRAPER_DATABASE_PASSWORD":      "plain",
+				"RSCRAPER_DATABASE_PASSWORD_FILE": secret,
+			},
+			check: func(t *testing.T, cfg *Config) {
+				if got := cfg.DBConfig.Password.Reveal(); got != "from-file" {
+					t.Errorf("database.password = %q, want the file contents without the newline", got)
+				}
+			},
+		},
+		{
+			name: "plain variable alone",
+			vars: map[string]string{"RSCRAPER_DATABASE_PASSWORD": "plain"},
+			check: func(t *testing.T, cfg *Config) {
+				if got := cfg.DBConfig.Password.Reveal(); got != "plain" {
+					t.Errorf("database.password = %q, want plain", got)
+				}
+			},
+		},
+		{
+			name: "unrelated variables ignored",
+			vars: map[string]string{"RSCRAPER_NOT_A_KEY": "1", "BASE_URL": "x", "RSCRAPER_SESSION_RUN_DATE": "2026-01-01"},
+			check: func(t *testing.T, cfg *Config) {
+				if !reflect.DeepEqual(cfg, NewConfig()) {
+					t.Errorf("config changed: %+v", cfg)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewConfig()
+			if err := cfg.applyEnv(lookupMap(tt.vars)); err != nil {
+				t.Fatalf("applyEnv() error = %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestApplyEnvErrors(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	vars := map[string]string{
+		"RSCRAPER_LISTINGS_PER_PAGE":      "five",
+		"RSCRAPER_HEADLESS":               "sometimes",
+		"RSCRAPER_RATE_LIMIT_BURST":       "2",
+		"RSCRAPER_RETRY_JITTER":           "lots",
+		"RSCRAPER_DATABASE_PASSWORD_FILE": missing,
+	}
+
+	cfg := NewConfig()
+	err := cfg.applyEnv(lookupMap(vars))
+
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) {
+		t.Fatalf("applyEnv() error = %v, want a *LoadError", err)
+	}
+	want := []string{
+		`RSCRAPER_LISTINGS_PER_PAGE: invalid integer "five"`,
+		`RSCRAPER_HEADLESS: invalid boolean "sometimes"`,
+		`RSCRAPER_RETRY_JITTER: invalid number "lots"`,
+		fmt.Sprintf("RSCRAPER_DATABASE_PASSWORD_FILE: open %s: no such file or directory", missing),
+	}
+	if !reflect.DeepEqual(loadErr.Problems, want) {
+		t.Errorf("problems = %q\nwant %q", loadErr.Problems, want)
+	}
+	if loadErr.Path != "environment" {
+		t.Errorf("path = %q, want environment", loadErr.Path)
+	}
+
+	// Valid variables still apply alongside the invalid ones
+	if cfg.RateLimit.Burst != 2 {
+		t.Errorf("rate_limit.burst = %d, want 2", cfg.RateLimit.Burst)
+	}
+}
+
+func TestSecretRedacted(t *testing.T) {
+	const password = "hunter2"
+	cfg := NewConfig()
+	cfg.DBConfig.Password = Secret(password)
+
+	var dump bytes.Buffer
+	if err := cfg.Dump(&dump); err != nil {
+		t.Fatal(err)
+	}
+	jsonDump, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outputs := map[string]string{
+		"Dump":         dump.String(),
+		"json.Marshal": string(jsonDump),
+		"%v":           fmt.Sprintf("%v", cfg.DBConfig),
+		"%+v":          fmt.Sprintf("%+v", cfg.DBConfig),
+		"%#v":          fmt.Sprintf("%#v", cfg.DBConfig),
+		"%s":           fmt.Sprintf("%s", cfg.DBConfig.Password),
+	}
+	for name, out := range outputs {
+		if strings.Contains(out, password) {
+			t.Errorf("%s reveals the password: %s", name, out)
+		}
+		if !strings.Contains(out, redacted) {
+			t.Errorf("%s has no %s placeholder: %s", name, redacted, out)
+		}
+	}
+
+	if got := cfg.DBConfig.Password.Reveal(); got != password {
+		t.Errorf("Reveal() = %q, want %q", got, password)
+	}
+	if got := Secret("").String(); got != "" {
+		t.Errorf("empty secret prints %q, want nothing", got)
+	}
+}
@@ -0,0 +1,207 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigPathEnv names the environment variable consulted when no config path is given
+const ConfigPathEnv = "RSCRAPER_CONFIG"
+
+// yamlLinePattern matches the "line N: message" prefix used in yaml.v3 errors
+var yamlLinePattern = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// movedKeys points removed config keys, by Go type and key, at their replacements
+var movedKeys = map[string]string{
+	"Config.page_timeout":            "timeouts.run",
+	"Config.request_delay":           "rate_limit.requests_per_second",
+	"DescriptionFetchConfig.timeout": "timeouts.detail_page",
+}
+
+// unknownFieldPattern matches yaml.v3's error for a key with no matching field
+var unknownFieldPattern = regexp.MustCompile(`field (\w+) not found in type config\.(\w+)`)
+
+// LoadError reports every problem found while decoding a config file
+type LoadError struct {
+	Path     string
+	Problems []string
+}
+
+func (e *LoadError) Error() string {
+	if len(e.Problems) == 1 {
+		return e.Problems[0]
+	}
+	return fmt.Sprintf("%s: %d problems:\n  %s", e.Path, len(e.Problems), strings.Join(e.Problems, "\n  "))
+}
+
+// ResolvePath returns the config path from the flag value, falling back to RSCRAPER_CONFIG
+func ResolvePath(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	return os.Getenv(ConfigPathEnv)
+}
+
+// Load resolves the effective configuration in layers: the defaults from NewConfig,
+// then the given file (skipped when path is empty), then RSCRAPER_* environment
+// variables and finally their *_FILE secret paths.
+func Load(path string) (*Config, error) {
+	cfg := NewConfig()
+
+	if path != "" {
+		if err := cfg.mergeFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.applyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// Dump writes the effective configuration as YAML with secrets redacted
+func (c *Config) Dump(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	return encoder.Close()
+}
+
+// mergeFile decodes a YAML, JSON or TOML file onto the config, keeping defaults for absent keys
+func (c *Config) mergeFile(path string) error {
+	format := strings.ToLower(filepath.Ext(path))
+	switch format {
+	case ".yaml", ".yml", ".json", ".toml":
+	default:
+		return fmt.Errorf("unsupported config format %q (use .yaml, .yml, .json or .toml)", path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config: %w", err)
+	}
+	defer file.Close()
+
+	if format == ".toml" {
+		decoder := toml.NewDecoder(file)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(c); err != nil {
+			return newTOMLLoadError(path, err)
+		}
+		return nil
+	}
+
+	// JSON is a subset of YAML, so one strict decoder covers both formats
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+
+	err = decoder.Decode(c)
+	if err == nil || errors.Is(err, io.EOF) {
+		return nil
+	}
+
+	return newLoadError(path, err)
+}
+
+// newLoadError rewrites yaml.v3 errors into "path:line: message" form
+func newLoadError(path string, err error) error {
+	var messages []string
+
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		messages = typeErr.Errors
+	} else {
+		messages = []string{err.Error()}
+	}
+
+	loadErr := &LoadError{Path: path}
+	for _, msg := range messages {
+		msg = explainMovedKey(msg)
+		if m := yamlLinePattern.FindStringSubmatch(msg); m != nil {
+			loadErr.Problems = append(loadErr.Problems, fmt.Sprintf("%s:%s: %s", path, m[1], m[2]))
+			continue
+		}
+		loadErr.Problems = append(loadErr.Problems, fmt.Sprintf("%s: %s", path, strings.TrimPrefix(msg, "yaml: ")))
+	}
+
+	return loadErr
+}
+
+// newTOMLLoadError rewrites go-toml errors into the same "path:line: message"
+// form as YAML ones, with one problem per unknown key
+func newTOMLLoadError(path string, err error) error {
+	loadErr := &LoadError{Path: path}
+
+	var strictErr *toml.StrictMissingError
+	var decodeErr *toml.DecodeError
+	switch {
+	case errors.As(err, &strictErr):
+		for _, e := range strictErr.Errors {
+			key := e.Key()
+			row, _ := e.Position()
+			msg := fmt.Sprintf("field %s not found in type config.%s", key[len(key)-1], tomlKeyOwner(key))
+			loadErr.Problems = append(loadErr.Problems, fmt.Sprintf("%s:%d: %s", path, row, explainMovedKey(msg)))
+		}
+	case errors.As(err, &decodeErr):
+		row, _ := decodeErr.Position()
+		loadErr.Problems = append(loadErr.Problems, fmt.Sprintf("%s:%d: %s", path, row, strings.TrimPrefix(decodeErr.Error(), "toml: ")))
+	default:
+		loadErr.Problems = append(loadErr.Problems, fmt.Sprintf("%s: %s", path, strings.TrimPrefix(err.Error(), "toml: ")))
+	}
+
+	return loadErr
+}
+
+// tomlKeyOwner names the config type that the last part of key would belong to
+func tomlKeyOwner(key []string) string {
+	t := reflect.TypeOf(Config{})
+	for _, part := range key[:len(key)-1] {
+		field, ok := fieldByTag(t, "toml", part)
+		if !ok {
+			break
+		}
+		t = field.Type
+		if t.Kind() == reflect.Slice {
+			t = t.Elem()
+		}
+	}
+	return t.Name()
+}
+
+// fieldByTag finds the struct field of t whose tag key names name
+func fieldByTag(t reflect.Type, key, name string) (reflect.StructField, bool) {
+	if t.Kind() != reflect.Struct {
+		return reflect.StructField{}, false
+	}
+	for i := range t.NumField() {
+		if field := t.Field(i); field.Tag.Get(key) == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// explainMovedKey adds the new location of a removed key to an unknown field error
+func explainMovedKey(msg string) string {
+	m := unknownFieldPattern.FindStringSubmatch(msg)
+	if m == nil {
+		return msg
+	}
+	if key, ok := movedKeys[m[2]+"."+m[1]]; ok {
+		return fmt.Sprintf("%s (moved to %s)", msg, key)
+	}
+	return msg
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadTOML(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		problems []string // Empty when the file loads
+	}{
+		{
+			name: "valid",
+			file: `
+listings_per_page = 7
+
+[[locations]]
+slug = "Seoul"
+display_name = "Seoul, South Korea"
+
+[timeouts]
+run = 600
+
+[database]
+password = "secret"
+`,
+		},
+		{
+			name: "unknown and moved keys",
+			file: `
+page_timeout = 30
+
+[[locations]]
+slug = "Seoul"
+colour = "red"
+
+[description]
+timeout = 3
+`,
+			problems: []string{
+				"config.toml:2: field page_timeout not found in type config.Config (moved to timeouts.run)",
+				"config.toml:6: field colour not found in type config.LocationConfig",
+				"config.toml:9: field timeout not found in type config.DescriptionFetchConfig (moved to timeouts.detail_page)",
+			},
+		},
+		{
+			name: "wrong type",
+			file: `
+max_listings = 1
+listings_per_page = "five"
+`,
+			problems: []string{
+				"config.toml:3: cannot decode TOML string into struct field config.Config.ListingsPerPage of type int",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Chdir(dir)
+			if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(tt.file), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := Load("config.toml")
+			if len(tt.problems) == 0 {
+				if err != nil {
+					t.Fatalf("Load() error = %v", err)
+				}
+				if cfg.ListingsPerPage != 7 || cfg.Timeouts.Run != 600 || cfg.DBConfig.Password.Reveal() != "secret" {
+					t.Errorf("Load() = %+v, want the file's values", cfg)
+				}
+				if len(cfg.Locations) != 1 || cfg.Locations[0].DisplayName != "Seoul, South Korea" {
+					t.Errorf("locations = %+v, want Seoul only", cfg.Locations)
+				}
+				return
+			}
+
+			var loadErr *LoadError
+			if !errors.As(err, &loadErr) {
+				t.Fatalf("Load() error = %v, want a *LoadError", err)
+			}
+			if !reflect.DeepEqual(loadErr.Problems, tt.problems) {
+				t.Errorf("problems = %q\nwant %q", loadErr.Problems, tt.problems)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package config
+
+import "strings"
+
+// Overrides holds per-run adjustments from the command line
+type Overrides struct {
+	Locations       []string // Slugs or city names to scrape; unknown entries are scraped as ad-hoc slugs
+	PagesToScrape   int      // -1 keeps the configured value, since zero means every page
+	ListingsPerPage int      // Zero keeps the configured value
+	MaxListings     int      // Zero keeps the configured value
+	FixturesDir     string   // Scrape saved pages from this directory instead of the live site
+	RecordPath      string   // Record the browser session to this archive
+	ReplayPath      string   // Replay a recorded archive instead of using a browser
+	ChromeWS        string   // Connect to a remote Chrome instead of launching one
+	DebugDir        string   // Save screenshots and HTML of failed pages here
+}
+
+// ApplyOverrides narrows or extends Locations and replaces the page settings for one run
+func (c *Config) ApplyOverrides(o Overrides) {
+	if len(o.Locations) > 0 {
+		selected := make([]LocationConfig, 0, len(o.Locations))
+		for _, name := range o.Locations {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if loc, ok := c.findLocation(name); ok {
+				selected = append(selected, loc)
+				continue
+			}
+			selected = append(selected, adHocLocation(name))
+		}
+		c.Locations = selected
+	}
+
+	if o.PagesToScrape != -1 {
+		c.PagesToScrape = o.PagesToScrape
+	}
+	if o.ListingsPerPage != 0 {
+		c.ListingsPerPage = o.ListingsPerPage
+	}
+	if o.MaxListings != 0 {
+		c.MaxListings = o.MaxListings
+	}
+	if o.FixturesDir != "" {
+		c.Fixtures = FixtureConfig{Dir: o.FixturesDir}
+	}
+	if o.RecordPath != "" {
+		c.Session.Record = o.RecordPath
+	}
+	if o.ReplayPath != "" {
+		c.Session.Replay = o.ReplayPath
+	}
+	if o.ChromeWS != "" {
+		c.Browser.RemoteURL = o.ChromeWS
+	}
+	if o.DebugDir != "" {
+		c.Debug.Dir = o.DebugDir
+	}
+}
+
+// findLocation matches a slug, a full display name or its city part ("Tokyo" for "Tokyo, Japan")
+func (c *Config) findLocation(name string) (LocationConfig, bool) {
+	for _, loc := range c.Locations {
+		city := strings.TrimSpace(strings.Split(loc.DisplayName, ",")[0])
+		if strings.EqualFold(loc.Slug, name) ||
+			strings.EqualFold(loc.DisplayName, name) ||
+			strings.EqualFold(city, name) {
+			return loc, true
+		}
+	}
+	return LocationConfig{}, false
+}
+
+// adHocLocation builds a location for a slug missing from the config
+func adHocLocation(name string) LocationConfig {
+	slug := strings.Join(strings.Fields(name), "-")
+	return LocationConfig{
+		Slug:        slug,
+		DisplayName: strings.ReplaceAll(slug, "-", " "),
+	}
+}
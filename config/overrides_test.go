@@ -0,0 +1,26 @@
+package config
+
+import "testing"
+
+func TestApplyOverridesPages(t *testing.T) {
+	tests := []struct {
+		name  string
+		pages int
+		want  int
+	}{
+		{name: "kept", pages: -1, want: 3},
+		{name: "every page", pages: 0, want: 0},
+		{name: "replaced", pages: 5, want: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewConfig()
+			cfg.PagesToScrape = 3
+			cfg.ApplyOverrides(Overrides{PagesToScrape: tt.pages})
+			if cfg.PagesToScrape != tt.want {
+				t.Errorf("pages_to_scrape = %d, want %d", cfg.PagesToScrape, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package config
+
+// redacted replaces secret values wherever they would be printed
+const redacted = "[redacted]"
+
+// Secret holds a credential that must never appear in logs or config dumps.
+// Formatting and marshalling it yield a placeholder; use Reveal to read it.
+type Secret string
+
+// Reveal returns the actual secret value
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+func (s Secret) GoString() string {
+	return `"` + s.String() + `"`
+}
+
+func (s Secret) MarshalYAML() (interface{}, error) {
+	return s.String(), nil
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + s.String() + `"`), nil
+}
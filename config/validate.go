@@ -0,0 +1,431 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ValidationError lists every problem found in a Config
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("configuration has %d problem(s):\n  - %s", len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+// Validate checks the whole config and reports all problems at once.
+// It returns nil or a *ValidationError.
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	c.validateBaseURL(v)
+
+	v.atLeast("listings_per_page", c.ListingsPerPage, 1)
+	v.atLeast("pages_to_scrape", c.PagesToScrape, 0)
+	v.atLeast("max_listings", c.MaxListings, 0)
+	v.atLeast("max_concurrent", c.MaxConcurrent, 1)
+	v.atLeast("description.max_concurrent", c.DescriptionConfig.MaxConcurrent, 1)
+	v.atLeast("timeouts.run", c.Timeouts.Run, 0)
+	v.atLeast("timeouts.location", c.Timeouts.Location, 0)
+	v.atLeast("timeouts.search_page", c.Timeouts.SearchPage, 1)
+	v.atLeast("timeouts.detail_page", c.Timeouts.DetailPage, 1)
+
+	c.validateRetry(v)
+	if c.RateLimit.RequestsPerSecond < 0 {
+		v.addf("rate_limit.requests_per_second must not be negative, got %g", c.RateLimit.RequestsPerSecond)
+	}
+	v.atLeast("rate_limit.burst", c.RateLimit.Burst, 1)
+	c.validateLocations(v)
+	c.validateDatabase(v)
+	c.validateFixtures(v)
+	c.validateSession(v)
+	c.validateBrowser(v)
+	c.validateBlocking(v)
+	c.validateProfiles(v)
+	c.validateProxies(v)
+	c.validateRobots(v)
+	c.validateSearches(v)
+	c.validateSweep(v)
+	v.atLeast("browser.max_tabs", c.Browser.MaxTabs, 0)
+	v.atLeast("browser.recycle_after", c.Browser.RecycleAfter, 0)
+	c.Waits.Search.validate(v, "waits.search")
+	c.Waits.Description.validate(v, "waits.description")
+	c.Waits.Search.fitsBudget(v, "waits.search", "timeouts.search_page", c.Timeouts.SearchPage)
+	c.Waits.Description.fitsBudget(v, "waits.description", "timeouts.detail_page", c.Timeouts.DetailPage)
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+func (c *Config) validateBaseURL(v *validator) {
+	if strings.Count(c.BaseURL, "%s") != 1 || strings.Count(c.BaseURL, "%") != 1 {
+		v.addf("base_url %q must contain exactly one %%s placeholder for the location slug", c.BaseURL)
+		return
+	}
+
+	u, err := url.Parse(fmt.Sprintf(c.BaseURL, "slug"))
+	if err != nil {
+		v.addf("base_url %q is not a valid URL: %v", c.BaseURL, err)
+		return
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		v.addf("base_url %q must use http or https", c.BaseURL)
+	}
+	if u.Host == "" {
+		v.addf("base_url %q has no host", c.BaseURL)
+	}
+	if u.RawQuery != "" {
+		v.addf("base_url %q must not contain a query string; pagination parameters are appended to it", c.BaseURL)
+	}
+}
+
+func (c *Config) validateRetry(v *validator) {
+	r := c.Retry
+	v.atLeast("retry.max_attempts", r.MaxAttempts, 1)
+	v.atLeast("retry.initial_backoff", r.InitialBackoff, 0)
+	v.atLeast("retry.max_backoff", r.MaxBackoff, r.InitialBackoff)
+	if r.Jitter < 0 || r.Jitter > 1 {
+		v.addf("retry.jitter must be between 0 and 1, got %g", r.Jitter)
+	}
+}
+
+func (c *Config) validateLocations(v *validator) {
+	if len(c.Locations) == 0 {
+		v.addf("locations must list at least one location")
+		return
+	}
+
+	seen := make(map[string]int)
+	for i, loc := range c.Locations {
+		if strings.TrimSpace(loc.Slug) == "" {
+			v.addf("locations[%d].slug must not be empty", i)
+			continue
+		}
+		if strings.ContainsAny(loc.Slug, " /?#") {
+			v.addf("locations[%d].slug %q must not contain spaces, '/', '?' or '#'", i, loc.Slug)
+		}
+
+		key := strings.ToLower(loc.Slug)
+		if first, ok := seen[key]; ok {
+			v.addf("locations[%d].slug %q duplicates locations[%d]", i, loc.Slug, first)
+			continue
+		}
+		seen[key] = i
+	}
+}
+
+func (c *Config) validateDatabase(v *validator) {
+	db := c.DBConfig
+	if strings.TrimSpace(db.Host) == "" {
+		v.addf("database.host must not be empty")
+	}
+	if db.Port < 1 || db.Port > 65535 {
+		v.addf("database.port must be between 1 and 65535, got %d", db.Port)
+	}
+	if strings.TrimSpace(db.User) == "" {
+		v.addf("database.user must not be empty")
+	}
+	if strings.TrimSpace(db.DBName) == "" {
+		v.addf("database.dbname must not be empty")
+	}
+}
+
+func (c *Config) validateFixtures(v *validator) {
+	f := c.Fixtures
+	if f.Dir != "" && f.URL != "" {
+		v.addf("fixtures.dir and fixtures.url are mutually exclusive")
+	}
+	if f.Dir != "" {
+		if info, err := os.Stat(f.Dir); err != nil || !info.IsDir() {
+			v.addf("fixtures.dir %q is not a readable directory", f.Dir)
+		}
+	}
+	if f.URL != "" {
+		if u, err := url.Parse(f.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			v.addf("fixtures.url %q must be an http(s) URL", f.URL)
+		}
+	}
+}
+
+func (c *Config) validateSession(v *validator) {
+	s := c.Session
+	if s.Record != "" && s.Replay != "" {
+		v.addf("session.record and session.replay are mutually exclusive")
+	}
+	if s.Replay != "" {
+		if _, err := os.Stat(s.Replay); err != nil {
+			v.addf("session.replay %q cannot be read: %v", s.Replay, err)
+		}
+		if c.Fixtures.Enabled() {
+			v.addf("session.replay cannot be combined with fixtures")
+		}
+	}
+}
+
+func (w WaitConfig) validate(v *validator, name string) {
+	switch w.Strategy {
+	case WaitSelector, WaitNetworkIdle, WaitSleep:
+	default:
+		v.addf("%s.strategy %q must be one of %s, %s or %s", name, w.Strategy, WaitSelector, WaitNetworkIdle, WaitSleep)
+	}
+
+	switch w.Fallback {
+	case "", WaitNone:
+	case WaitSelector, WaitNetworkIdle, WaitSleep:
+		v.atLeast(name+".fallback_timeout", w.FallbackTimeout, 1)
+	default:
+		v.addf("%s.fallback %q must be one of %s, %s, %s or %s", name, w.Fallback, WaitSelector, WaitNetworkIdle, WaitSleep, WaitNone)
+	}
+
+	v.atLeast(name+".timeout", w.Timeout, 1)
+	v.atLeast(name+".min_count", w.MinCount, 0)
+}
+
+func (c *Config) validateProfiles(v *validator) {
+	seen := make(map[string]int)
+	for i, p := range c.Profiles {
+		switch {
+		case strings.TrimSpace(p.Name) == "":
+			v.addf("profiles[%d].name must not be empty", i)
+		case p.Name == ProfileRotate:
+			v.addf("profiles[%d].name %q is reserved", i, p.Name)
+		default:
+			if first, ok := seen[p.Name]; ok {
+				v.addf("profiles[%d].name %q duplicates profiles[%d]", i, p.Name, first)
+			}
+			seen[p.Name] = i
+		}
+		if p.ViewportWidth < 0 || p.ViewportHeight < 0 || (p.ViewportWidth == 0) != (p.ViewportHeight == 0) {
+			v.addf("profiles[%d] viewport_width and viewport_height must both be positive, or both 0", i)
+		}
+	}
+
+	switch name := c.Browser.Profile; {
+	case name == "":
+	case name == ProfileRotate:
+		if len(c.Profiles) == 0 {
+			v.addf("browser.profile %q needs at least one entry in profiles", name)
+		}
+	default:
+		if _, ok := c.Profile(name); !ok {
+			v.addf("browser.profile %q is not defined in profiles", name)
+		}
+	}
+
+	for i, loc := range c.Locations {
+		if loc.Profile == "" || loc.Profile == ProfileRotate {
+			continue
+		}
+		if _, ok := c.Profile(loc.Profile); !ok {
+			v.addf("locations[%d].profile %q is not defined in profiles", i, loc.Profile)
+		}
+	}
+}
+
+func (c *Config) validateProxies(v *validator) {
+	names := make(map[string]int)
+	regions := make(map[string]bool)
+	for i, p := range c.Proxies {
+		if strings.TrimSpace(p.Name) == "" {
+			v.addf("proxies[%d].name must not be empty", i)
+		} else if first, ok := names[p.Name]; ok {
+			v.addf("proxies[%d].name %q duplicates proxies[%d]", i, p.Name, first)
+		} else {
+			names[p.Name] = i
+		}
+		if p.Region != "" {
+			regions[p.Region] = true
+		}
+
+		u, err := url.Parse(p.URL)
+		switch {
+		case err != nil || u.Host == "" || u.Port() == "":
+			v.addf("proxies[%d].url %q must be scheme://host:port", i, p.URL)
+		case u.User != nil:
+			v.addf("proxies[%d].url must not contain credentials; use username and password", i)
+		case u.Scheme == "socks5":
+			if p.Username != "" || p.Password != "" {
+				v.addf("proxies[%d]: Chrome does not support credentials for SOCKS5 proxies", i)
+			}
+		case u.Scheme != "http" && u.Scheme != "https":
+			v.addf("proxies[%d].url %q must use http, https or socks5", i, p.URL)
+		}
+	}
+
+	if len(c.Proxies) > 0 {
+		v.atLeast("proxy.ban_after", c.Proxy.BanAfter, 1)
+		v.atLeast("proxy.ban_duration", c.Proxy.BanDuration, 0)
+	}
+
+	for i, loc := range c.Locations {
+		if loc.Proxy == "" {
+			continue
+		}
+		if _, ok := names[loc.Proxy]; !ok && !regions[loc.Proxy] {
+			v.addf("locations[%d].proxy %q is neither a proxy name nor a region in proxies", i, loc.Proxy)
+		}
+	}
+}
+
+func (c *Config) validateSearches(v *validator) {
+	// A replayed session keeps the dates it was recorded with, even once they have passed
+	today, replay := c.Today(), c.Session.Replay != ""
+	top := &validator{}
+	c.Search.validate(top, "search", today, replay)
+	v.problems = append(v.problems, top.problems...)
+
+	for i, loc := range c.Locations {
+		if loc.Search == (SearchConfig{}) {
+			continue
+		}
+		// Problems the location inherits are reported once, under search
+		merged := &validator{}
+		c.LocationSearch(i).validate(merged, "search", today, replay)
+		for _, problem := range merged.problems {
+			if !slices.Contains(top.problems, problem) {
+				v.addf("locations[%d].%s", i, problem)
+			}
+		}
+	}
+}
+
+func (s SearchConfig) validate(v *validator, prefix string, today time.Time, allowPast bool) {
+	checkIn, inErr := ResolveDate(s.CheckIn, today)
+	if inErr != nil {
+		v.addf("%s.check_in: %v", prefix, inErr)
+	}
+	checkOut, outErr := ResolveDate(s.CheckOut, today)
+	if outErr != nil {
+		v.addf("%s.check_out: %v", prefix, outErr)
+	}
+	if inErr == nil && outErr == nil {
+		switch {
+		case checkIn.IsZero() != checkOut.IsZero():
+			v.addf("%s: check_in and check_out must be set together", prefix)
+		case checkIn.IsZero():
+		case !checkOut.After(checkIn):
+			v.addf("%s.check_out %s must be after check_in %s", prefix, checkOut.Format(time.DateOnly), checkIn.Format(time.DateOnly))
+		case !allowPast && checkIn.Format(time.DateOnly) < today.Format(time.DateOnly):
+			v.addf("%s.check_in %s is in the past", prefix, checkIn.Format(time.DateOnly))
+		}
+	}
+
+	v.atLeast(prefix+".adults", s.Adults, 0)
+	v.atLeast(prefix+".children", s.Children, 0)
+	if s.Children > 0 && s.Adults == 0 {
+		v.addf("%s: children need at least one adult", prefix)
+	}
+	v.atLeast(prefix+".price_min", s.PriceMin, 0)
+	v.atLeast(prefix+".price_max", s.PriceMax, 0)
+	if s.PriceMax > 0 && s.PriceMax < s.PriceMin {
+		v.addf("%s.price_max %d is below price_min %d", prefix, s.PriceMax, s.PriceMin)
+	}
+	if s.RoomType != "" && !slices.Contains(RoomTypes, s.RoomType) {
+		v.addf("%s.room_type %q must be one of %s", prefix, s.RoomType, strings.Join(RoomTypes, ", "))
+	}
+	if s.PropertyType != "" && !slices.Contains(PropertyTypes, s.PropertyType) {
+		v.addf("%s.property_type %q must be one of %s", prefix, s.PropertyType, strings.Join(PropertyTypes, ", "))
+	}
+}
+
+func (c *Config) validateSweep(v *validator) {
+	v.atLeast("sweep.windows", c.Sweep.Windows, 0)
+	if !c.Sweep.Enabled() {
+		return
+	}
+
+	// A replayed sweep keeps the windows it was recorded with
+	today := c.Today()
+	if first, err := c.Sweep.FirstCheckIn(today); err != nil {
+		v.addf("sweep: %v", err)
+	} else if c.Session.Replay == "" && first.Format(time.DateOnly) < today.Format(time.DateOnly) {
+		v.addf("sweep.start %s is in the past", first.Format(time.DateOnly))
+	}
+	v.atLeast("sweep.nights", c.Sweep.Nights, 1)
+	v.atLeast("sweep.every", c.Sweep.Every, 0)
+}
+
+// robotsTokenPattern is the product token syntax of RFC 9309
+var robotsTokenPattern = regexp.MustCompile(`^[a-zA-Z_-]+$`)
+
+func (c *Config) validateRobots(v *validator) {
+	if c.Robots.Enabled && !robotsTokenPattern.MatchString(c.Robots.UserAgent) {
+		v.addf("robots.user_agent %q must be a product token of letters, '-' and '_'", c.Robots.UserAgent)
+	}
+	for i, pattern := range c.Robots.Allow {
+		if !strings.HasPrefix(pattern, "/") && !strings.HasPrefix(pattern, "*") {
+			v.addf("robots.allow[%d] %q must be a path starting with '/' or '*'", i, pattern)
+		}
+	}
+}
+
+// blockableResourceTypes are the resource types blocking.resource_types accepts;
+// documents are left out, since blocking them would stop every page loading
+var blockableResourceTypes = []string{
+	"stylesheet", "image", "media", "font", "script", "texttrack", "xhr", "fetch",
+	"prefetch", "eventsource", "websocket", "manifest", "ping", "other",
+}
+
+func (c *Config) validateBlocking(v *validator) {
+	for i, name := range c.Blocking.ResourceTypes {
+		if !slices.Contains(blockableResourceTypes, strings.ToLower(name)) {
+			v.addf("blocking.resource_types[%d] %q must be one of %s", i, name, strings.Join(blockableResourceTypes, ", "))
+		}
+	}
+	for i, pattern := range c.Blocking.URLPatterns {
+		if strings.Trim(pattern, "*?") == "" {
+			v.addf("blocking.url_patterns[%d] %q would block every request", i, pattern)
+		}
+	}
+}
+
+// fitsBudget reports a wait that can outlast the page budget it runs under,
+// which would turn every slow page into a timeout instead of a fallback
+func (w WaitConfig) fitsBudget(v *validator, name, budgetName string, budget int) {
+	total := w.Timeout
+	if w.Fallback != "" && w.Fallback != WaitNone {
+		total += w.FallbackTimeout
+	}
+	if budget > 0 && total >= budget {
+		v.addf("%s can take up to %ds, which does not fit in %s (%ds)", name, total, budgetName, budget)
+	}
+}
+
+func (c *Config) validateBrowser(v *validator) {
+	if c.Browser.RemoteURL == "" {
+		return
+	}
+	u, err := url.Parse(c.Browser.RemoteURL)
+	if err != nil || u.Host == "" {
+		v.addf("browser.remote_url %q must be a URL such as ws://host:9222", c.Browser.RemoteURL)
+		return
+	}
+	switch u.Scheme {
+	case "ws", "wss", "http", "https":
+	default:
+		v.addf("browser.remote_url %q must use ws, wss, http or https", c.Browser.RemoteURL)
+	}
+}
+
+// validator accumulates problems so every one is reported in a single pass
+type validator struct {
+	problems []string
+}
+
+func (v *validator) addf(format string, args ...interface{}) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+func (v *validator) atLeast(name string, value, min int) {
+	if value < min {
+		v.addf("%s must be at least %d, got %d", name, min, value)
+	}
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	// A replayed session must exist to be read
+	recording := filepath.Join(t.TempDir(), "session.jsonl.gz")
+	if err := os.WriteFile(recording, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		mutate   func(cfg *Config)
+		problems []string // Empty when the config is valid
+	}{
+		{
+			name:   "defaults",
+			mutate: func(cfg *Config) {},
+		},
+		{
+			name: "basics",
+			mutate: func(cfg *Config) {
+				cfg.BaseURL = "https://www.airbnb.com/s/homes"
+				cfg.ListingsPerPage = 0
+				cfg.Locations = []LocationConfig{{Slug: "Seoul"}, {Slug: "Busan"}, {Slug: "Seoul"}}
+			},
+			problems: []string{
+				`base_url "https://www.airbnb.com/s/homes" must contain exactly one %s placeholder for the location slug`,
+				"listings_per_page must be at least 1, got 0",
+				`locations[2].slug "Seoul" duplicates locations[0]`,
+			},
+		},
+		{
+			name: "past and inverted dates",
+			mutate: func(cfg *Config) {
+				cfg.Search.CheckIn, cfg.Search.CheckOut = "2026-02-20", "2026-02-22"
+				cfg.Locations[1].Search = SearchConfig{CheckIn: "2026-03-10", CheckOut: "2026-03-08"}
+			},
+			problems: []string{
+				"search.check_in 2026-02-20 is in the past",
+				"locations[1].search.check_out 2026-03-08 must be after check_in 2026-03-10",
+			},
+		},
+		{
+			name: "past dates allowed on replay",
+			mutate: func(cfg *Config) {
+				cfg.Session.Replay = recording
+				cfg.Search.CheckIn, cfg.Search.CheckOut = "2026-02-20", "2026-02-22"
+				cfg.Sweep = SweepConfig{Windows: 2, Start: "2026-02-01", Nights: 2}
+			},
+		},
+		{
+			name: "search inherited by locations",
+			mutate: func(cfg *Config) {
+				cfg.Search = SearchConfig{Children: 1, PriceMin: 200, PriceMax: 100}
+				cfg.Locations[0].Search = SearchConfig{RoomType: "castle"}
+				cfg.Locations[2].Search = SearchConfig{Adults: 2}
+			},
+			problems: []string{
+				"search: children need at least one adult",
+				"search.price_max 100 is below price_min 200",
+				`locations[0].search.room_type "castle" must be one of ` + strings.Join(RoomTypes, ", "),
+			},
+		},
+		{
+			name: "sweep",
+			mutate: func(cfg *Config) {
+				cfg.Sweep = SweepConfig{Windows: 3, Start: "2026-02-01", Nights: 0, Every: -1}
+			},
+			problems: []string{
+				"sweep.start 2026-02-01 is in the past",
+				"sweep.nights must be at least 1, got 0",
+				"sweep.every must be at least 0, got -1",
+			},
+		},
+		{
+			name: "sweep weekday unknown",
+			mutate: func(cfg *Config) {
+				cfg.Sweep = SweepConfig{Windows: 1, Start: "+1d", Weekday: "Caturday", Nights: 2}
+			},
+			problems: []string{`sweep: unknown weekday "Caturday"`},
+		},
+		{
+			name:   "sweep disabled",
+			mutate: func(cfg *Config) { cfg.Sweep = SweepConfig{Start: "2026-02-01"} },
+		},
+		{
+			name: "robots",
+			mutate: func(cfg *Config) {
+				cfg.Robots.UserAgent = "rental-scraper/1.0"
+				cfg.Robots.Allow = []string{"/rooms/", "*.json", "s/"}
+			},
+			problems: []string{
+				`robots.user_agent "rental-scraper/1.0" must be a product token of letters, '-' and '_'`,
+				`robots.allow[2] "s/" must be a path starting with '/' or '*'`,
+			},
+		},
+		{
+			name: "robots user agent unchecked when disabled",
+			mutate: func(cfg *Config) {
+				cfg.Robots.Enabled = false
+				cfg.Robots.UserAgent = "Mozilla/5.0"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewConfig()
+			cfg.Session.RunDate = time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)
+			tt.mutate(cfg)
+
+			err := cfg.Validate()
+			if len(tt.problems) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Validate() error = %v, want a *ValidationError", err)
+			}
+			if !reflect.DeepEqual(validationErr.Problems, tt.problems) {
+				t.Errorf("problems = %q\nwant %q", validationErr.Problems, tt.problems)
+			}
+		})
+	}
+}
@@ -1,11 +1,25 @@
 package models
 
 type Listing struct {
-	Platform    string
-	Title       string
-	Price       string
-	Location    string
-	Rating      string
-	URL         string
-	Description string
-}
\ No newline at end of file
+	Platform    string       `json:"platform"`
+	Title       string       `json:"title"`
+	Price       string       `json:"price"`
+	Location    string       `json:"location"`
+	Rating      string       `json:"rating"`
+	URL         string       `json:"url"`
+	Description string       `json:"description"`
+	Search      SearchParams `json:"search"`
+}
+
+// SearchParams are the search filters a listing was found with; zero values were not set
+type SearchParams struct {
+	CheckIn      string `json:"check_in,omitempty"`  // YYYY-MM-DD
+	CheckOut     string `json:"check_out,omitempty"` // YYYY-MM-DD
+	Adults       int    `json:"adults,omitempty"`
+	Children     int    `json:"children,omitempty"`
+	PriceMin     int    `json:"price_min,omitempty"`
+	PriceMax     int    `json:"price_max,omitempty"`
+	RoomType     string `json:"room_type,omitempty"`
+	PropertyType string `json:"property_type,omitempty"`
+	Window       int    `json:"window,omitempty"` // Stay window of a date sweep, from 1
+}
@@ -0,0 +1,254 @@
+package scraper
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Archive entry kinds
+const (
+	entryRun      = "run" // First entry: the run date the searches were resolved against
+	entryNavigate = "navigate"
+	entryEvaluate = "evaluate"
+	entrySnapshot = "snapshot"
+	entryResponse = "response"
+)
+
+// archiveEntry is one line of a session archive (gzip-compressed JSON lines)
+type archiveEntry struct {
+	Kind       string          `json:"kind"`
+	Time       time.Time       `json:"time"`
+	RunDate    string          `json:"run_date,omitempty"` // YYYY-MM-DD, on the run entry
+	URL        string          `json:"url"`
+	Scope      string          `json:"scope,omitempty"`       // Search the visit belongs to, see withVisitScope
+	Visit      int             `json:"visit,omitempty"`       // Navigations to URL within Scope so far, from 1
+	PageURL    string          `json:"page_url,omitempty"`    // Page that issued a network response
+	ScriptHash string          `json:"script_hash,omitempty"` // SHA-256 of an evaluated script
+	Result     json.RawMessage `json:"result,omitempty"`      // JSON result of an evaluation
+	HTML       string          `json:"html,omitempty"`        // DOM snapshot
+	Status     int64           `json:"status,omitempty"`
+	MimeType   string          `json:"mime_type,omitempty"`
+	Body       []byte          `json:"body,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// NetworkResponse is a response observed while a page loaded
+type NetworkResponse struct {
+	URL      string
+	Status   int64
+	MimeType string
+	Body     []byte
+}
+
+// ResponseObserver is implemented by fetchers that can report network traffic
+type ResponseObserver interface {
+	// ObserveResponses calls fn for every response received by the tab in ctx
+	ObserveResponses(ctx context.Context, fn func(NetworkResponse)) error
+}
+
+// ArchiveWriter appends entries to a session archive; safe for concurrent use
+type ArchiveWriter struct {
+	mu      sync.Mutex
+	file    *os.File
+	gz      *gzip.Writer
+	buf     *bufio.Writer
+	encoder *json.Encoder
+}
+
+// CreateArchive creates (or truncates) an archive file for a run whose
+// relative dates resolve against runDate, so a replay can resolve them alike
+func CreateArchive(path string, runDate time.Time) (*ArchiveWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive: %w", err)
+	}
+
+	gz := gzip.NewWriter(file)
+	buf := bufio.NewWriter(gz)
+
+	w := &ArchiveWriter{
+		file:    file,
+		gz:      gz,
+		buf:     buf,
+		encoder: json.NewEncoder(buf),
+	}
+	if err := w.write(archiveEntry{Kind: entryRun, RunDate: runDate.Format(time.DateOnly)}); err != nil {
+		w.Close()
+		return nil, fmt.Errorf("failed to create archive: %w", err)
+	}
+	return w, nil
+}
+
+func (w *ArchiveWriter) write(entry archiveEntry) error {
+	entry.Time = time.Now().UTC()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.encoder.Encode(entry)
+}
+
+// Close flushes and closes the archive
+func (w *ArchiveWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return errors.Join(w.buf.Flush(), w.gz.Close(), w.file.Close())
+}
+
+// Archive is a recorded session loaded into memory for replay
+type Archive struct {
+	runDate     time.Time
+	navigations map[string]archiveEntry // keyed by visit
+	evaluations map[string]archiveEntry // keyed by visit + script hash
+	snapshots   map[string]archiveEntry // keyed by visit
+	responses   map[string][]NetworkResponse
+}
+
+// LoadArchive reads an archive written by ArchiveWriter.
+// Later entries for the same key win, matching the final state of the recorded run.
+func LoadArchive(path string) (*Archive, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive %s: %w", path, err)
+	}
+	defer gz.Close()
+
+	archive := &Archive{
+		navigations: make(map[string]archiveEntry),
+		evaluations: make(map[string]archiveEntry),
+		snapshots:   make(map[string]archiveEntry),
+		responses:   make(map[string][]NetworkResponse),
+	}
+
+	decoder := json.NewDecoder(gz)
+	for line := 1; ; line++ {
+		var entry archiveEntry
+		if err := decoder.Decode(&entry); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("archive %s: entry %d: %w", path, line, err)
+		}
+
+		// Archives from before the run entry fall back to the day recording started
+		if line == 1 {
+			archive.runDate = entry.Time.Local()
+		}
+
+		switch entry.Kind {
+		case entryRun:
+			runDate, err := time.ParseInLocation(time.DateOnly, entry.RunDate, time.Local)
+			if err != nil {
+				return nil, fmt.Errorf("archive %s: entry %d: invalid run date: %w", path, line, err)
+			}
+			archive.runDate = runDate
+		case entryNavigate:
+			archive.navigations[entry.visit().key()] = entry
+		case entryEvaluate:
+			archive.evaluations[evaluationKey(entry.visit(), entry.ScriptHash)] = entry
+		case entrySnapshot:
+			archive.snapshots[entry.visit().key()] = entry
+		case entryResponse:
+			archive.responses[entry.PageURL] = append(archive.responses[entry.PageURL], NetworkResponse{
+				URL:      entry.URL,
+				Status:   entry.Status,
+				MimeType: entry.MimeType,
+				Body:     entry.Body,
+			})
+		}
+	}
+
+	return archive, nil
+}
+
+// RunDate returns the day the recorded run resolved its relative dates against
+func (a *Archive) RunDate() time.Time {
+	return a.runDate
+}
+
+// Responses returns the network responses recorded while pageURL was loaded
+func (a *Archive) Responses(pageURL string) []NetworkResponse {
+	return a.responses[pageURL]
+}
+
+// recordedVisit returns v as the archive holds it: archives recorded before
+// visits were numbered hold one unnumbered visit per URL
+func (a *Archive) recordedVisit(v pageVisit) (pageVisit, bool) {
+	if _, ok := a.navigations[v.key()]; ok {
+		return v, true
+	}
+	unnumbered := pageVisit{url: v.url}
+	_, ok := a.navigations[unnumbered.key()]
+	return unnumbered, ok
+}
+
+func evaluationKey(v pageVisit, scriptHash string) string {
+	return v.key() + "\x00" + scriptHash
+}
+
+type visitScopeKey struct{}
+
+// withVisitScope names the search that pages visited from ctx belong to.
+// Visits are numbered per scope and URL, so searches running side by side, such as
+// the stay windows of a sweep, keep their own visits of a page when replayed.
+func withVisitScope(ctx context.Context, scope string) context.Context {
+	return context.WithValue(ctx, visitScopeKey{}, scope)
+}
+
+// visitScopeFrom returns the scope of pages visited from ctx, or "" outside a search
+func visitScopeFrom(ctx context.Context) string {
+	scope, _ := ctx.Value(visitScopeKey{}).(string)
+	return scope
+}
+
+// pageVisit is one navigation to a URL, the nth within its scope
+type pageVisit struct {
+	scope string
+	url   string
+	n     int
+}
+
+func (v pageVisit) key() string {
+	return v.scope + "\x00" + v.url + "\x00" + strconv.Itoa(v.n)
+}
+
+// entry returns an archive entry of kind recorded during the visit
+func (v pageVisit) entry(kind string) archiveEntry {
+	return archiveEntry{Kind: kind, URL: v.url, Scope: v.scope, Visit: v.n}
+}
+
+func (e archiveEntry) visit() pageVisit {
+	return pageVisit{scope: e.Scope, url: e.URL, n: e.Visit}
+}
+
+// visitCounter numbers the navigations to each URL within a scope
+type visitCounter map[string]int
+
+func (c visitCounter) next(scope, url string) pageVisit {
+	key := scope + "\x00" + url
+	c[key]++
+	return pageVisit{scope: scope, url: url, n: c[key]}
+}
+
+func hashScript(script string) string {
+	sum := sha256.Sum256([]byte(script))
+	return hex.EncodeToString(sum[:])
+}
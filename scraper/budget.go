@@ -0,0 +1,59 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Timeout budgets, from the widest to the narrowest
+const (
+	BudgetRun        = "run"
+	BudgetLocation   = "location"
+	BudgetSearchPage = "search page"
+	BudgetDetailPage = "detail page"
+)
+
+// BudgetError reports which timeout budget ran out. It matches
+// context.DeadlineExceeded with errors.Is.
+type BudgetError struct {
+	Budget string        // One of the Budget* constants
+	Limit  time.Duration // Configured length of the budget
+	Target string        // Location name or URL the budget covered; empty for the run
+}
+
+func (e *BudgetError) Error() string {
+	if e.Target == "" {
+		return fmt.Sprintf("%s timeout of %v exceeded", e.Budget, e.Limit)
+	}
+	return fmt.Sprintf("%s timeout of %v exceeded for %s", e.Budget, e.Limit, e.Target)
+}
+
+func (e *BudgetError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// WithBudget bounds ctx by a budget of seconds; 0 or less leaves it unbounded.
+// When the budget runs out, context.Cause reports a *BudgetError.
+func WithBudget(ctx context.Context, budget string, seconds int, target string) (context.Context, context.CancelFunc) {
+	if seconds <= 0 {
+		return context.WithCancel(ctx)
+	}
+	limit := time.Duration(seconds) * time.Second
+	return context.WithTimeoutCause(ctx, limit, &BudgetError{Budget: budget, Limit: limit, Target: target})
+}
+
+// explainTimeout replaces err with the budget that expired, when ctx ended
+// because one did, so the message names the timeout instead of a bare
+// "context deadline exceeded"
+func explainTimeout(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil {
+		return err
+	}
+	var budget *BudgetError
+	if errors.As(context.Cause(ctx), &budget) {
+		return budget
+	}
+	return err
+}
@@ -0,0 +1,129 @@
+package scraper
+
+import (
+	"context"
+	"sync"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/emon51/rental-scraper/utils"
+)
+
+// screenshotQuality is the JPEG quality of debug screenshots
+const screenshotQuality = 80
+
+// ChromeFetcher implements Fetcher with chromedp, leasing tabs from a BrowserPool.
+// Every call must use a context returned by NewTab.
+type ChromeFetcher struct {
+	pool *utils.BrowserPool
+}
+
+func NewChromeFetcher(pool *utils.BrowserPool) *ChromeFetcher {
+	return &ChromeFetcher{pool: pool}
+}
+
+// NewTab leases a tab through the proxy set with WithProxy from the pool and
+// applies the profile set with WithProfile.
+// The returned context is cancelled along with ctx, and the cancel function
+// hands the tab back to the pool.
+func (f *ChromeFetcher) NewTab(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	tab, err := f.pool.Acquire(ctx, proxyFrom(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := utils.ApplyProfile(tab.Context(), profileFrom(ctx)); err != nil {
+		tab.MarkBroken()
+		f.pool.Release(tab)
+		return nil, nil, err
+	}
+
+	tabCtx, cancel := context.WithCancelCause(tab.Context())
+	stop := context.AfterFunc(ctx, func() { cancel(context.Cause(ctx)) })
+
+	return tabCtx, func() {
+		stop()
+		cancel(context.Canceled)
+		f.pool.Release(tab)
+	}, nil
+}
+
+func (f *ChromeFetcher) Navigate(ctx context.Context, url string) error {
+	if tab, ok := utils.TabFromContext(ctx); ok {
+		tab.CountNavigation()
+	}
+	return f.checkTab(ctx, chromedp.Run(ctx, chromedp.Navigate(url)))
+}
+
+func (f *ChromeFetcher) Evaluate(ctx context.Context, script string, res interface{}) error {
+	return f.checkTab(ctx, chromedp.Run(ctx, chromedp.Evaluate(script, res)))
+}
+
+func (f *ChromeFetcher) HTML(ctx context.Context) (string, error) {
+	var html string
+	err := chromedp.Run(ctx, chromedp.OuterHTML("html", &html, chromedp.ByQuery))
+	return html, f.checkTab(ctx, err)
+}
+
+func (f *ChromeFetcher) Screenshot(ctx context.Context) ([]byte, error) {
+	var buf []byte
+	err := chromedp.Run(ctx, chromedp.FullScreenshot(&buf, screenshotQuality))
+	return buf, f.checkTab(ctx, err)
+}
+
+func (f *ChromeFetcher) BlockedRequests() map[string]int64 {
+	return f.pool.BlockedRequests()
+}
+
+// checkTab retires the tab when an error came from the tab itself going away
+func (f *ChromeFetcher) checkTab(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if tab, ok := utils.TabFromContext(ctx); ok && tab.Context().Err() != nil {
+		tab.MarkBroken()
+	}
+	return err
+}
+
+// ObserveResponses reports document, XHR and fetch responses with their bodies
+func (f *ChromeFetcher) ObserveResponses(ctx context.Context, fn func(NetworkResponse)) error {
+	var pending sync.Map // network.RequestID -> *network.Response
+
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		switch e := ev.(type) {
+		case *network.EventResponseReceived:
+			switch e.Type {
+			case network.ResourceTypeDocument, network.ResourceTypeXHR, network.ResourceTypeFetch:
+				pending.Store(e.RequestID, e.Response)
+			}
+		case *network.EventLoadingFinished:
+			value, ok := pending.LoadAndDelete(e.RequestID)
+			if !ok {
+				return
+			}
+			resp := value.(*network.Response)
+
+			// Listeners must not block, so the body is fetched separately
+			go func() {
+				c := chromedp.FromContext(ctx)
+				if c == nil || c.Target == nil {
+					return
+				}
+				body, err := network.GetResponseBody(e.RequestID).Do(cdp.WithExecutor(ctx, c.Target))
+				if err != nil {
+					body = nil
+				}
+				fn(NetworkResponse{
+					URL:      resp.URL,
+					Status:   resp.Status,
+					MimeType: resp.MimeType,
+					Body:     body,
+				})
+			}()
+		}
+	})
+
+	return chromedp.Run(ctx, network.Enable())
+}
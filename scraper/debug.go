@@ -0,0 +1,98 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// debugDumpTimeout bounds capturing one page, so a hung tab cannot stall a worker
+const debugDumpTimeout = 15 * time.Second
+
+// unsafeNameChars matches characters replaced in debug file names
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// roomIDPattern extracts the listing ID from a room URL
+var roomIDPattern = regexp.MustCompile(`/rooms/(\d+)`)
+
+// DebugDumper saves a screenshot and the outer HTML of pages that yielded too
+// little into one directory per run: <dir>/<run>/<location>/page-2.{jpg,html}
+// or <dir>/<run>/<location>/listing-12345.{jpg,html}
+type DebugDumper struct {
+	dir string
+}
+
+// NewDebugDumper creates a dumper writing under root in a directory named after
+// the run's start time; an empty root returns nil, which disables dumps
+func NewDebugDumper(root string, started time.Time) *DebugDumper {
+	if root == "" {
+		return nil
+	}
+	return &DebugDumper{dir: filepath.Join(root, started.Format("20060102-150405"))}
+}
+
+// Dump captures the current page of the tab in ctx as name.html and name.jpg
+// and returns the files written. A fetcher without screenshots still gets its
+// HTML saved.
+func (d *DebugDumper) Dump(ctx context.Context, fetcher Fetcher, name string) ([]string, error) {
+	base := filepath.Join(d.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(base), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create debug directory: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, debugDumpTimeout)
+	defer cancel()
+
+	html, err := fetcher.HTML(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture HTML: %w", err)
+	}
+	if err := os.WriteFile(base+".html", []byte(html), 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write debug HTML: %w", err)
+	}
+	files := []string{base + ".html"}
+
+	shot, err := fetcher.Screenshot(ctx)
+	if errors.Is(err, ErrNoScreenshot) {
+		return files, nil
+	}
+	if err != nil {
+		return files, fmt.Errorf("failed to capture screenshot: %w", err)
+	}
+	if err := os.WriteFile(base+".jpg", shot, 0o644); err != nil {
+		return files, fmt.Errorf("failed to write debug screenshot: %w", err)
+	}
+
+	return append(files, base+".jpg"), nil
+}
+
+// searchDumpName names the dump of a search page
+func searchDumpName(locationSlug string, window, page int) string {
+	return fmt.Sprintf("%s/page-%d", dumpDir(locationSlug, window), page)
+}
+
+// listingDumpName names the dump of a listing page by its room ID
+func listingDumpName(locationSlug string, window int, url string) string {
+	id := safeName(url)
+	if m := roomIDPattern.FindStringSubmatch(url); m != nil {
+		id = m[1]
+	}
+	return fmt.Sprintf("%s/listing-%s", dumpDir(locationSlug, window), id)
+}
+
+// dumpDir keeps the dumps of each sweep window apart
+func dumpDir(locationSlug string, window int) string {
+	if window == 0 {
+		return safeName(locationSlug)
+	}
+	return fmt.Sprintf("%s/window-%d", safeName(locationSlug), window)
+}
+
+func safeName(s string) string {
+	return strings.Trim(unsafeNameChars.ReplaceAllString(s, "_"), "_")
+}
@@ -0,0 +1,120 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Structured reasons for a page that loaded but yielded nothing
+var (
+	// ErrBlocked means the site served a block page, a captcha or a login wall
+	ErrBlocked = errors.New("blocked by site")
+
+	// ErrNoResults means the search is valid but has no listings
+	ErrNoResults = errors.New("no results")
+
+	// ErrLayoutChanged means listings appear to be present but the selectors no longer match
+	ErrLayoutChanged = errors.New("page layout changed")
+
+	// ErrIncomplete means the page showed neither listings nor a no-results
+	// message, as happens when it has not finished loading; worth retrying
+	ErrIncomplete = errors.New("page incomplete")
+)
+
+// Failure reasons used in the run summary
+const (
+	ReasonBlocked       = "blocked"
+	ReasonNoResults     = "no results"
+	ReasonLayoutChanged = "layout changed"
+	ReasonTimeout       = "timeout"
+	ReasonDisallowed    = "disallowed"
+	ReasonOther         = "other"
+)
+
+// FailureReasons lists every reason in reporting order
+var FailureReasons = []string{ReasonBlocked, ReasonNoResults, ReasonLayoutChanged, ReasonTimeout, ReasonDisallowed, ReasonOther}
+
+// FailureReason classifies err for reporting
+func FailureReason(err error) string {
+	switch {
+	case errors.Is(err, ErrBlocked):
+		return ReasonBlocked
+	case errors.Is(err, ErrNoResults):
+		return ReasonNoResults
+	case errors.Is(err, ErrLayoutChanged):
+		return ReasonLayoutChanged
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, ErrIncomplete):
+		return ReasonTimeout
+	case errors.Is(err, ErrDisallowed):
+		return ReasonDisallowed
+	default:
+		return ReasonOther
+	}
+}
+
+// pageProbe is the result of PageProbeScript
+type pageProbe struct {
+	URL          string `json:"url"`
+	Title        string `json:"title"`
+	Captcha      bool   `json:"captcha"`
+	Blocked      bool   `json:"blocked"`
+	Login        bool   `json:"login"`
+	NoResults    bool   `json:"no_results"`
+	Cards        int    `json:"cards"`
+	ListingLinks int    `json:"listing_links"`
+}
+
+// blockReason returns an ErrBlocked error if the probe saw a block page, captcha or login wall
+func (p pageProbe) blockReason() error {
+	switch {
+	case p.Captcha:
+		return fmt.Errorf("%w: captcha challenge at %s", ErrBlocked, p.URL)
+	case p.Login:
+		return fmt.Errorf("%w: redirected to login at %s", ErrBlocked, p.URL)
+	case p.Blocked:
+		return fmt.Errorf("%w: %q", ErrBlocked, p.Title)
+	}
+	return nil
+}
+
+// probePage inspects the current page of the tab in ctx
+func (s *Scraper) probePage(ctx context.Context) (pageProbe, error) {
+	var probe pageProbe
+	if err := s.fetcher.Evaluate(ctx, PageProbeScript, &probe); err != nil {
+		return probe, fmt.Errorf("page probe failed: %w", err)
+	}
+	return probe, nil
+}
+
+// explainEmptySearch says why a search page yielded no listings
+func (s *Scraper) explainEmptySearch(ctx context.Context) error {
+	probe, err := s.probePage(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := probe.blockReason(); err != nil {
+		return err
+	}
+	if probe.NoResults {
+		return ErrNoResults
+	}
+	if probe.Cards == 0 && probe.ListingLinks > 0 {
+		return fmt.Errorf("%w: %d listing links but no %s cards", ErrLayoutChanged, probe.ListingLinks, ItemListSelector)
+	}
+	if probe.ListingLinks == 0 {
+		return fmt.Errorf("%w: no listings and no no-results message at %s", ErrIncomplete, probe.URL)
+	}
+	return fmt.Errorf("%w: %d cards found but none extracted", ErrLayoutChanged, probe.Cards)
+}
+
+// explainEmptyDescription reports a blocked detail page; a page that merely
+// has no description section is not an error
+func (s *Scraper) explainEmptyDescription(ctx context.Context) error {
+	probe, err := s.probePage(ctx)
+	if err != nil {
+		return err
+	}
+	return probe.blockReason()
+}
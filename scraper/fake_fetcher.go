@@ -0,0 +1,128 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// FakePage is a canned response served by FakeFetcher
+type FakePage struct {
+	HTML string
+
+	// Result is returned from Evaluate when EvaluateFunc is nil
+	Result interface{}
+
+	// EvaluateFunc computes the Evaluate result per script when set
+	EvaluateFunc func(script string) (interface{}, error)
+
+	// Err makes Navigate to this page fail
+	Err error
+}
+
+// FakeFetcher implements Fetcher from in-memory pages, for tests and offline work.
+// Like a real browser each tab holds one current page.
+type FakeFetcher struct {
+	mu      sync.Mutex
+	pages   map[string]FakePage
+	main    fakeTab
+	visited []string
+}
+
+type fakeTab struct {
+	current string
+}
+
+func NewFakeFetcher(pages map[string]FakePage) *FakeFetcher {
+	return &FakeFetcher{pages: pages}
+}
+
+// Visited returns every URL passed to Navigate, in order
+func (f *FakeFetcher) Visited() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.visited...)
+}
+
+func (f *FakeFetcher) NewTab(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	tabCtx, cancel := withTab(ctx, f, &fakeTab{})
+	return tabCtx, cancel, nil
+}
+
+func (f *FakeFetcher) Navigate(ctx context.Context, url string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.visited = append(f.visited, url)
+
+	page, ok := f.pages[url]
+	if !ok {
+		return fmt.Errorf("fake fetcher: no page for %s", url)
+	}
+	if page.Err != nil {
+		return page.Err
+	}
+
+	tabFrom(ctx, f, &f.main).current = url
+	return nil
+}
+
+func (f *FakeFetcher) Evaluate(ctx context.Context, script string, res interface{}) error {
+	page, err := f.currentPage(ctx)
+	if err != nil {
+		return err
+	}
+
+	result := page.Result
+	if page.EvaluateFunc != nil {
+		if result, err = page.EvaluateFunc(script); err != nil {
+			return err
+		}
+	}
+
+	if res == nil {
+		return nil
+	}
+
+	// Round-trip through JSON to mirror how a browser returns values
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("fake fetcher: %w", err)
+	}
+	return json.Unmarshal(data, res)
+}
+
+func (f *FakeFetcher) HTML(ctx context.Context) (string, error) {
+	page, err := f.currentPage(ctx)
+	if err != nil {
+		return "", err
+	}
+	return page.HTML, nil
+}
+
+func (f *FakeFetcher) Screenshot(ctx context.Context) ([]byte, error) {
+	return nil, ErrNoScreenshot
+}
+
+func (f *FakeFetcher) currentPage(ctx context.Context) (FakePage, error) {
+	if err := ctx.Err(); err != nil {
+		return FakePage{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	current := tabFrom(ctx, f, &f.main).current
+	if current == "" {
+		return FakePage{}, fmt.Errorf("fake fetcher: no page loaded")
+	}
+	return f.pages[current], nil
+}
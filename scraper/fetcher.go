@@ -0,0 +1,63 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNoScreenshot is returned by fetchers that have no rendered page to capture
+var ErrNoScreenshot = errors.New("screenshots not supported")
+
+// Fetcher drives a browser tab on behalf of the Scraper.
+// The tab travels in ctx, so a single Fetcher serves every tab derived from it.
+type Fetcher interface {
+	// NewTab opens a separate tab; calls made with the returned context act on it.
+	// The cancel function closes the tab.
+	NewTab(ctx context.Context) (context.Context, context.CancelFunc, error)
+
+	// Navigate loads url and returns once the page has loaded
+	Navigate(ctx context.Context, url string) error
+
+	// Evaluate runs a JavaScript expression and decodes its JSON result into res
+	Evaluate(ctx context.Context, script string, res interface{}) error
+
+	// HTML returns the outer HTML of the current document
+	HTML(ctx context.Context) (string, error)
+
+	// Screenshot captures the whole current page as a JPEG
+	Screenshot(ctx context.Context) ([]byte, error)
+}
+
+// BlockedRequestCounter is implemented by fetchers whose browser blocks requests
+type BlockedRequestCounter interface {
+	// BlockedRequests returns the requests blocked so far, by resource type
+	BlockedRequests() map[string]int64
+}
+
+// BlockedRequests returns the blocked request counts of f, or nil if it blocks nothing
+func BlockedRequests(f Fetcher) map[string]int64 {
+	if counter, ok := f.(BlockedRequestCounter); ok {
+		return counter.BlockedRequests()
+	}
+	return nil
+}
+
+// tabKey stores per-tab state in a context; owner keeps wrapped fetchers apart
+type tabKey struct {
+	owner interface{}
+}
+
+// withTab returns a child of ctx carrying state for owner's new tab
+func withTab[T any](ctx context.Context, owner interface{}, state *T) (context.Context, context.CancelFunc) {
+	tabCtx, cancel := context.WithCancel(ctx)
+	return context.WithValue(tabCtx, tabKey{owner}, state), cancel
+}
+
+// tabFrom returns owner's state for the tab in ctx, or fallback when ctx is not
+// inside a tab opened by owner
+func tabFrom[T any](ctx context.Context, owner interface{}, fallback *T) *T {
+	if state, ok := ctx.Value(tabKey{owner}).(*T); ok {
+		return state
+	}
+	return fallback
+}
@@ -0,0 +1,153 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FixturePath maps a page URL to its file inside a fixtures directory.
+// The host is dropped and the query, if any, is appended after "__":
+//
+//	https://www.airbnb.com/s/Tokyo/homes                -> s/Tokyo/homes.html
+//	https://www.airbnb.com/s/Tokyo/homes?cursor=eyJz     -> s/Tokyo/homes__cursor=eyJz.html
+//	https://www.airbnb.com/rooms/12345                  -> rooms/12345.html
+func FixturePath(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid fixture URL %q: %w", rawURL, err)
+	}
+
+	name := strings.Trim(path.Clean("/"+u.Path), "/")
+	if name == "" {
+		name = "index"
+	}
+	if query := u.Query().Encode(); query != "" {
+		name += "__" + query
+	}
+
+	return filepath.FromSlash(name) + ".html", nil
+}
+
+// FixtureServer serves saved pages from a directory on a local port
+type FixtureServer struct {
+	dir      string
+	server   *http.Server
+	listener net.Listener
+}
+
+// StartFixtureServer serves dir on addr until Close is called.
+// An empty addr picks a random loopback port.
+func StartFixtureServer(dir, addr string) (*FixtureServer, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("fixtures directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("fixtures directory: %s is not a directory", dir)
+	}
+
+	if addr == "" {
+		addr = "127.0.0.1:0"
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start fixture server: %w", err)
+	}
+
+	fs := &FixtureServer{dir: dir, listener: listener}
+	fs.server = &http.Server{
+		Handler:           http.HandlerFunc(fs.serve),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		if err := fs.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Printf("  WARNING: fixture server stopped: %v\n", err)
+		}
+	}()
+
+	return fs, nil
+}
+
+// URL returns the base URL of the server
+func (fs *FixtureServer) URL() string {
+	return "http://" + fs.listener.Addr().String()
+}
+
+// Close stops the server
+func (fs *FixtureServer) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return fs.server.Shutdown(ctx)
+}
+
+// serve answers page requests from FixturePath and anything else, such as
+// stylesheets saved next to the pages, as a static file
+func (fs *FixtureServer) serve(w http.ResponseWriter, r *http.Request) {
+	name, err := FixturePath(r.URL.String())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	file := filepath.Join(fs.dir, name)
+	if _, err := os.Stat(file); err == nil {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		http.ServeFile(w, r, file)
+		return
+	}
+
+	http.FileServer(http.Dir(fs.dir)).ServeHTTP(w, r)
+}
+
+// FixtureFetcher redirects every navigation to a fixture server, keeping the
+// path and query, so the normal extraction runs against saved pages
+type FixtureFetcher struct {
+	Fetcher
+	baseURL *url.URL
+}
+
+// NewFixtureFetcher wraps inner so pages are loaded from the server at baseURL
+func NewFixtureFetcher(inner Fetcher, baseURL string) (*FixtureFetcher, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid fixture server URL %q", baseURL)
+	}
+	return &FixtureFetcher{Fetcher: inner, baseURL: u}, nil
+}
+
+func (f *FixtureFetcher) Navigate(ctx context.Context, rawURL string) error {
+	target, err := f.rewrite(rawURL)
+	if err != nil {
+		return err
+	}
+	return f.Fetcher.Navigate(ctx, target)
+}
+
+func (f *FixtureFetcher) BlockedRequests() map[string]int64 {
+	return BlockedRequests(f.Fetcher)
+}
+
+// rewrite swaps the scheme and host of rawURL for the fixture server's
+func (f *FixtureFetcher) rewrite(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+
+	u.Scheme = f.baseURL.Scheme
+	u.Host = f.baseURL.Host
+	u.Path = strings.TrimSuffix(f.baseURL.Path, "/") + u.Path
+
+	return u.String(), nil
+}
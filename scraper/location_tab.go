@@ -0,0 +1,84 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/emon51/rental-scraper/config"
+)
+
+// locationTab is a worker's tab for one location. It is reopened when the
+// location moves to another proxy after a block, or when the tab itself dies.
+type locationTab struct {
+	fetcher Fetcher
+	parent  context.Context
+
+	ctx   context.Context // Pass to fetcher calls; replaced on reopen
+	close context.CancelFunc
+	proxy *config.ProxyConfig // Proxy the tab was opened through
+}
+
+func (s *Scraper) openLocationTab(ctx context.Context) (*locationTab, error) {
+	t := &locationTab{fetcher: s.fetcher, parent: ctx}
+	if err := t.open(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *locationTab) open() error {
+	proxy := proxyFrom(t.parent)
+	tabCtx, closeTab, err := t.fetcher.NewTab(t.parent)
+	if err != nil {
+		return fmt.Errorf("failed to open tab: %w", err)
+	}
+	t.ctx, t.close, t.proxy = tabCtx, closeTab, proxy
+	return nil
+}
+
+// Close releases the tab
+func (t *locationTab) Close() {
+	if t.close != nil {
+		t.close()
+	}
+}
+
+// settle records the outcome of a page load against the tab's proxy. After a
+// block the location rotates to another proxy and the tab is reopened through
+// it; a tab that died while the location still has time is reopened too.
+// Once the location is out of time the outcome is not the proxy's doing.
+func (t *locationTab) settle(err error) {
+	if t.parent.Err() != nil {
+		return
+	}
+
+	assignment, _ := t.parent.Value(proxyKey{}).(*ProxyAssignment)
+	assignment.Report(t.proxy, err)
+
+	reopen := errors.Is(err, ErrBlocked) && assignment.Rotate(t.proxy)
+	if t.ctx.Err() != nil {
+		reopen = true
+	}
+	if !reopen {
+		return
+	}
+
+	from := proxyLabel(t.proxy)
+	t.Close()
+	t.close = nil
+	if err := t.open(); err != nil {
+		fmt.Printf("    WARNING: failed to reopen tab: %v\n", err)
+		return
+	}
+	if to := proxyLabel(t.proxy); to != from {
+		fmt.Printf("    Switched proxy %s -> %s\n", from, to)
+	}
+}
+
+func proxyLabel(proxy *config.ProxyConfig) string {
+	if proxy == nil {
+		return "direct"
+	}
+	return proxy.Name
+}
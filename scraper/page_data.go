@@ -0,0 +1,320 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/emon51/rental-scraper/models"
+)
+
+// pageData is the result of PageDataScript: the raw text of the script blocks
+// that carry a page's structured data
+type pageData struct {
+	DeferredState []string `json:"deferred_state"`
+	JSONLD        []string `json:"json_ld"`
+}
+
+// searchData is what a search page's structured data says about its results
+type searchData struct {
+	Listings   []models.Listing
+	NextCursor string // Cursor of the next page; empty on the last page or when unknown
+}
+
+// deferredState is the data-deferred-state block Airbnb hydrates its pages from.
+// niobeMinimalClientData holds [query key, payload] pairs.
+type deferredState struct {
+	NiobeMinimalClientData [][]json.RawMessage `json:"niobeMinimalClientData"`
+}
+
+// staysSearchPayload is the payload of the search results query
+type staysSearchPayload struct {
+	Data struct {
+		Presentation struct {
+			StaysSearch struct {
+				Results struct {
+					SearchResults  []staysSearchResult `json:"searchResults"`
+					PaginationInfo struct {
+						NextPageCursor string `json:"nextPageCursor"`
+					} `json:"paginationInfo"`
+				} `json:"results"`
+			} `json:"staysSearch"`
+		} `json:"presentation"`
+	} `json:"data"`
+}
+
+// staysSearchResult is one listing card of the search results
+type staysSearchResult struct {
+	Title              string `json:"title"`
+	AvgRatingLocalized string `json:"avgRatingLocalized"` // e.g. "4.85 (123)" or "New"
+	Listing            struct {
+		ID                 string `json:"id"`
+		Name               string `json:"name"`
+		Title              string `json:"title"`
+		AvgRatingLocalized string `json:"avgRatingLocalized"`
+	} `json:"listing"`
+	DemandStayListing struct {
+		ID string `json:"id"` // base64 of "DemandStayListing:<room ID>"
+	} `json:"demandStayListing"`
+	StructuredDisplayPrice struct {
+		PrimaryLine struct {
+			Price           string `json:"price"`
+			DiscountedPrice string `json:"discountedPrice"`
+			OriginalPrice   string `json:"originalPrice"`
+		} `json:"primaryLine"`
+	} `json:"structuredDisplayPrice"`
+}
+
+// jsonLDNode is the subset of schema.org used to describe listings in JSON-LD
+type jsonLDNode struct {
+	Type            jsonLDTypes  `json:"@type"`
+	Graph           []jsonLDNode `json:"@graph"`
+	ItemListElement []struct {
+		Item jsonLDNode `json:"item"`
+	} `json:"itemListElement"`
+	Name            string `json:"name"`
+	URL             string `json:"url"`
+	AggregateRating *struct {
+		RatingValue jsonLDValue `json:"ratingValue"`
+	} `json:"aggregateRating"`
+	Offers *struct {
+		Price         jsonLDValue `json:"price"`
+		PriceCurrency string      `json:"priceCurrency"`
+	} `json:"offers"`
+}
+
+// jsonLDTypes accepts @type as a string or a list of strings
+type jsonLDTypes []string
+
+func (t *jsonLDTypes) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = jsonLDTypes{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*t = many
+	return nil
+}
+
+// jsonLDValue accepts a value written as a string or a number
+type jsonLDValue string
+
+func (v *jsonLDValue) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*v = jsonLDValue(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*v = jsonLDValue(n.String())
+	return nil
+}
+
+var ratingPattern = regexp.MustCompile(`^\d+(?:[.,]\d+)?`)
+
+// extractFromPageData reads up to limit listings from the structured data of
+// the search page in ctx. No listings and no error means the page has no
+// usable data and the DOM heuristics should be used instead.
+func (s *Scraper) extractFromPageData(ctx context.Context, pageURL string, limit int) (searchData, error) {
+	var raw pageData
+	if err := s.fetcher.Evaluate(ctx, PageDataScript, &raw); err != nil {
+		if ctx.Err() != nil {
+			return searchData{}, err
+		}
+		// Archives recorded before this script existed cannot answer it
+		if !errors.Is(err, ErrNotRecorded) {
+			s.logger.Error(fmt.Sprintf("Failed to read structured data on %s", pageURL), err)
+		}
+		return searchData{}, nil
+	}
+
+	origin := "https://www.airbnb.com"
+	if u, err := url.Parse(pageURL); err == nil && u.Host != "" {
+		origin = u.Scheme + "://" + u.Host
+	}
+
+	data := parseDeferredState(raw.DeferredState, origin)
+	if len(data.Listings) == 0 {
+		data.Listings = parseJSONLD(raw.JSONLD)
+	}
+	if len(data.Listings) > limit {
+		data.Listings = data.Listings[:limit]
+	}
+	return data, nil
+}
+
+// parseDeferredState collects the search results from data-deferred-state blocks
+func parseDeferredState(blocks []string, origin string) searchData {
+	var data searchData
+	for _, block := range blocks {
+		var state deferredState
+		if err := json.Unmarshal([]byte(block), &state); err != nil {
+			continue
+		}
+
+		for _, entry := range state.NiobeMinimalClientData {
+			if len(entry) < 2 {
+				continue
+			}
+			var payload staysSearchPayload
+			if err := json.Unmarshal(entry[1], &payload); err != nil {
+				continue
+			}
+
+			results := payload.Data.Presentation.StaysSearch.Results
+			for _, result := range results.SearchResults {
+				if listing, ok := result.toListing(origin); ok {
+					data.Listings = append(data.Listings, listing)
+				}
+			}
+			if results.PaginationInfo.NextPageCursor != "" {
+				data.NextCursor = results.PaginationInfo.NextPageCursor
+			}
+		}
+	}
+	return data
+}
+
+// toListing converts a search result, reporting false when it has no room ID or title.
+// The title is the listing's name, as on the card and in JSON-LD; the type line
+// such as "Apartment in Mapo-gu" is used only when there is no name.
+func (r staysSearchResult) toListing(origin string) (models.Listing, bool) {
+	id := r.roomID()
+	title := firstNonEmpty(r.Listing.Name, r.Listing.Title, r.Title)
+	if id == "" || title == "" {
+		return models.Listing{}, false
+	}
+
+	price := r.StructuredDisplayPrice.PrimaryLine
+	return models.Listing{
+		Title:  title,
+		Price:  firstNonEmpty(price.DiscountedPrice, price.Price, price.OriginalPrice),
+		Rating: parseRating(firstNonEmpty(r.AvgRatingLocalized, r.Listing.AvgRatingLocalized)),
+		URL:    origin + "/rooms/" + id,
+	}, true
+}
+
+// roomID returns the numeric room ID from either listing reference
+func (r staysSearchResult) roomID() string {
+	if isDigits(r.Listing.ID) {
+		return r.Listing.ID
+	}
+	decoded, err := base64.StdEncoding.DecodeString(r.DemandStayListing.ID)
+	if err != nil {
+		return ""
+	}
+	if _, id, ok := strings.Cut(string(decoded), ":"); ok && isDigits(id) {
+		return id
+	}
+	return ""
+}
+
+// parseJSONLD collects the listings of JSON-LD item lists
+func parseJSONLD(blocks []string) []models.Listing {
+	var listings []models.Listing
+	for _, block := range blocks {
+		var nodes []jsonLDNode
+		trimmed := bytes.TrimSpace([]byte(block))
+		if bytes.HasPrefix(trimmed, []byte("[")) {
+			if err := json.Unmarshal(trimmed, &nodes); err != nil {
+				continue
+			}
+		} else {
+			var node jsonLDNode
+			if err := json.Unmarshal(trimmed, &node); err != nil {
+				continue
+			}
+			nodes = []jsonLDNode{node}
+		}
+
+		for _, node := range nodes {
+			listings = append(listings, node.listings()...)
+		}
+	}
+	return listings
+}
+
+// listings returns the listings in an ItemList node, looking inside @graph
+func (n jsonLDNode) listings() []models.Listing {
+	var listings []models.Listing
+	for _, child := range n.Graph {
+		listings = append(listings, child.listings()...)
+	}
+	for _, element := range n.ItemListElement {
+		item := element.Item
+		if item.Name == "" || !roomIDPattern.MatchString(item.URL) {
+			continue
+		}
+
+		listing := models.Listing{Title: item.Name, URL: item.URL}
+		if item.AggregateRating != nil {
+			listing.Rating = parseRating(string(item.AggregateRating.RatingValue))
+		}
+		if item.Offers != nil && item.Offers.Price != "" {
+			listing.Price = strings.TrimSpace(item.Offers.PriceCurrency + " " + string(item.Offers.Price))
+		}
+		listings = append(listings, listing)
+	}
+	return listings
+}
+
+// parseRating returns the leading rating of a localized value such as
+// "4.85 (123)", or "" when it is not a rating from 1 to 5
+func parseRating(s string) string {
+	match := ratingPattern.FindString(strings.TrimSpace(s))
+	if match == "" {
+		return ""
+	}
+	match = strings.Replace(match, ",", ".", 1)
+	if rating, err := strconv.ParseFloat(match, 64); err != nil || rating < 1 || rating > 5 {
+		return ""
+	}
+	return match
+}
+
+// withCursor returns pageURL set to load the page after cursor
+func withCursor(pageURL, cursor string) (string, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", pageURL, err)
+	}
+	query := u.Query()
+	query.Set("cursor", cursor)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,202 @@
+package scraper
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/emon51/rental-scraper/models"
+)
+
+func TestParseDeferredState(t *testing.T) {
+	payload, err := os.ReadFile("testdata/deferred_state.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		blocks []string
+		want   searchData
+	}{
+		{
+			name:   "search results",
+			blocks: []string{string(payload)},
+			want: searchData{
+				Listings: []models.Listing{
+					{
+						Title:  "Quiet loft near Hongdae station",
+						Price:  "$92",
+						Rating: "4.92",
+						URL:    "https://www.airbnb.com/rooms/53951723",
+					},
+					{
+						Title: "Hanok stay with garden",
+						Price: "$150",
+						URL:   "https://www.airbnb.com/rooms/1044887461853921",
+					},
+				},
+				NextCursor: "eyJzZWN0aW9uX29mZnNldCI6MCwiaXRlbXNfb2Zmc2V0IjoxOCwidmVyc2lvbiI6MX0=",
+			},
+		},
+		{
+			name:   "invalid blocks skipped",
+			blocks: []string{"not json", `{"niobeMinimalClientData": [["only a key"]]}`, string(payload)},
+			want: searchData{
+				Listings: []models.Listing{
+					{Title: "Quiet loft near Hongdae station", Price: "$92", Rating: "4.92", URL: "https://www.airbnb.com/rooms/53951723"},
+					{Title: "Hanok stay with garden", Price: "$150", URL: "https://www.airbnb.com/rooms/1044887461853921"},
+				},
+				NextCursor: "eyJzZWN0aW9uX29mZnNldCI6MCwiaXRlbXNfb2Zmc2V0IjoxOCwidmVyc2lvbiI6MX0=",
+			},
+		},
+		{
+			name:   "other queries only",
+			blocks: []string{`{"niobeMinimalClientData": [["ExploreHeader:{}", {"data": {}}]]}`},
+		},
+		{
+			name: "no blocks",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseDeferredState(tt.blocks, "https://www.airbnb.com")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseDeferredState() = %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoomID(t *testing.T) {
+	tests := []struct {
+		name      string
+		listingID string
+		demandID  string
+		want      string
+	}{
+		{name: "numeric listing ID", listingID: "53951723", demandID: "RGVtYW5kU3RheUxpc3Rpbmc6MQ==", want: "53951723"},
+		{name: "demand stay listing ID", demandID: "RGVtYW5kU3RheUxpc3Rpbmc6NTM5NTE3MjM=", want: "53951723"},
+		{name: "non-numeric listing ID", listingID: "U3RheUxpc3Rpbmc6MQ==", demandID: "RGVtYW5kU3RheUxpc3Rpbmc6NTM5NTE3MjM=", want: "53951723"},
+		{name: "decoded ID not numeric", demandID: "RGVtYW5kU3RheUxpc3Rpbmc6YWJj"},
+		{name: "not base64", demandID: "DemandStayListing:53951723"},
+		{name: "no separator", demandID: "NTM5NTE3MjM="},
+		{name: "empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r staysSearchResult
+			r.Listing.ID = tt.listingID
+			r.DemandStayListing.ID = tt.demandID
+			if got := r.roomID(); got != tt.want {
+				t.Errorf("roomID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseJSONLD(t *testing.T) {
+	tests := []struct {
+		name   string
+		blocks []string
+		want   []models.Listing
+	}{
+		{
+			name: "item list",
+			blocks: []string{`{
+				"@context": "https://schema.org",
+				"@type": "ItemList",
+				"itemListElement": [
+					{
+						"@type": "ListItem",
+						"position": 1,
+						"item": {
+							"@type": "LodgingBusiness",
+							"name": "Quiet loft near Hongdae station",
+							"url": "https://www.airbnb.com/rooms/53951723",
+							"aggregateRating": {"@type": "AggregateRating", "ratingValue": 4.92, "reviewCount": 118},
+							"offers": {"@type": "Offer", "price": "92", "priceCurrency": "USD"}
+						}
+					},
+					{
+						"@type": "ListItem",
+						"position": 2,
+						"item": {"@type": "LodgingBusiness", "name": "Hanok stay", "url": "https://www.airbnb.com/rooms/1044887461853921"}
+					}
+				]
+			}`},
+			want: []models.Listing{
+				{Title: "Quiet loft near Hongdae station", Price: "USD 92", Rating: "4.92", URL: "https://www.airbnb.com/rooms/53951723"},
+				{Title: "Hanok stay", URL: "https://www.airbnb.com/rooms/1044887461853921"},
+			},
+		},
+		{
+			name: "graph with type list",
+			blocks: []string{`{
+				"@context": "https://schema.org",
+				"@graph": [
+					{"@type": ["WebPage"], "name": "Seoul stays"},
+					{"@type": ["ItemList", "Thing"], "itemListElement": [
+						{"item": {"name": "Loft", "url": "https://www.airbnb.com/rooms/1", "aggregateRating": {"ratingValue": "4,8"}}}
+					]}
+				]
+			}`},
+			want: []models.Listing{
+				{Title: "Loft", Rating: "4.8", URL: "https://www.airbnb.com/rooms/1"},
+			},
+		},
+		{
+			name:   "top-level array",
+			blocks: []string{` [{"@type": "ItemList", "itemListElement": [{"item": {"name": "Loft", "url": "https://www.airbnb.com/rooms/1"}}]}]`},
+			want:   []models.Listing{{Title: "Loft", URL: "https://www.airbnb.com/rooms/1"}},
+		},
+		{
+			name: "items without a name or room URL skipped",
+			blocks: []string{`{"@type": "ItemList", "itemListElement": [
+				{"item": {"name": "", "url": "https://www.airbnb.com/rooms/1"}},
+				{"item": {"name": "Experience", "url": "https://www.airbnb.com/experiences/2"}}
+			]}`},
+		},
+		{
+			name:   "invalid blocks skipped",
+			blocks: []string{"{", `{"@type": 5}`, `{"@type": "Organization", "name": "Airbnb"}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseJSONLD(tt.blocks)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseJSONLD() = %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRating(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"4.85 (123)", "4.85"},
+		{"4.9", "4.9"},
+		{"5", "5"},
+		{"1.0", "1.0"},
+		{" 4.7 (12) ", "4.7"},
+		{"4,85 (123)", "4.85"},
+		{"New", ""},
+		{"", ""},
+		{"0.5", ""},
+		{"5.01", ""},
+		{"123 reviews", ""},
+		{"Rated 4.9", ""},
+	}
+
+	for _, tt := range tests {
+		if got := parseRating(tt.in); got != tt.want {
+			t.Errorf("parseRating(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/emon51/rental-scraper/models"
+)
+
+// nextPageURL returns the absolute URL of the next search page linked from the
+// page in ctx, or "" on the last page. A link that cannot be read ends the
+// pagination rather than failing a page whose listings were extracted.
+func (s *Scraper) nextPageURL(ctx context.Context, pageURL string) string {
+	var next string
+	if err := s.fetcher.Evaluate(ctx, NextPageScript, &next); err != nil {
+		fmt.Printf("  WARNING: could not read the next-page link of %s: %v\n", pageURL, err)
+		return ""
+	}
+	return next
+}
+
+// pageFingerprint identifies a search page by the rooms it lists, ignoring
+// per-page tracking parameters in the links
+func pageFingerprint(listings []models.Listing) string {
+	rooms := make([]string, 0, len(listings))
+	for _, listing := range listings {
+		room := listing.URL
+		if u, err := url.Parse(listing.URL); err == nil {
+			room = u.Path
+		}
+		rooms = append(rooms, room)
+	}
+	sort.Strings(rooms)
+	return strings.Join(rooms, "\n")
+}
@@ -0,0 +1,31 @@
+package scraper
+
+import (
+	"context"
+
+	"github.com/emon51/rental-scraper/config"
+)
+
+type profileKey struct{}
+
+// WithProfile makes tabs opened from ctx use profile
+func WithProfile(ctx context.Context, profile *config.ProfileConfig) context.Context {
+	return context.WithValue(ctx, profileKey{}, profile)
+}
+
+// profileFrom returns the profile for tabs opened from ctx, or nil for the defaults
+func profileFrom(ctx context.Context) *config.ProfileConfig {
+	profile, _ := ctx.Value(profileKey{}).(*config.ProfileConfig)
+	return profile
+}
+
+// locationProfiles maps each configured location slug to its browser profile
+func locationProfiles(cfg *config.Config) map[string]*config.ProfileConfig {
+	profiles := make(map[string]*config.ProfileConfig)
+	for i, loc := range cfg.Locations {
+		if profile, ok := cfg.LocationProfile(i); ok {
+			profiles[loc.Slug] = &profile
+		}
+	}
+	return profiles
+}
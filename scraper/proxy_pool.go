@@ -0,0 +1,225 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emon51/rental-scraper/config"
+)
+
+// ProxyPool hands proxies to locations and benches the ones that keep failing
+type ProxyPool struct {
+	banAfter    int
+	banDuration time.Duration
+	now         func() time.Time
+
+	mu      sync.Mutex
+	proxies []*proxyState
+	next    int // Round-robin position
+}
+
+// proxyState tracks the health of one proxy
+type proxyState struct {
+	cfg         config.ProxyConfig
+	successes   int
+	failures    int
+	consecutive int // Failures since the last success
+	bans        int
+	bannedUntil time.Time
+}
+
+// NewProxyPool creates a pool from the configured proxies, or returns nil when there are none
+func NewProxyPool(cfg *config.Config) *ProxyPool {
+	if len(cfg.Proxies) == 0 {
+		return nil
+	}
+
+	pool := &ProxyPool{
+		banAfter:    cfg.Proxy.BanAfter,
+		banDuration: time.Duration(cfg.Proxy.BanDuration) * time.Second,
+		now:         time.Now,
+	}
+	for _, p := range cfg.Proxies {
+		pool.proxies = append(pool.proxies, &proxyState{cfg: p})
+	}
+	return pool
+}
+
+// Assign picks a proxy for a location. selector is a proxy name or region;
+// empty draws from every proxy. A nil pool assigns nothing.
+func (p *ProxyPool) Assign(selector string) *ProxyAssignment {
+	if p == nil {
+		return nil
+	}
+	a := &ProxyAssignment{pool: p, selector: selector}
+	a.current = p.pick(selector, nil)
+	return a
+}
+
+// pick returns the next healthy candidate other than exclude, in turn. When
+// every candidate is benched, the one whose ban ends first is used rather
+// than falling back to a direct connection.
+func (p *ProxyPool) pick(selector string, exclude *proxyState) *proxyState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	var fallback *proxyState
+	for i := range p.proxies {
+		candidate := p.proxies[(p.next+i)%len(p.proxies)]
+		if selector != "" && candidate.cfg.Name != selector && candidate.cfg.Region != selector {
+			continue
+		}
+		if fallback == nil || candidate.bannedUntil.Before(fallback.bannedUntil) {
+			fallback = candidate
+		}
+		if candidate == exclude || now.Before(candidate.bannedUntil) {
+			continue
+		}
+		p.next = (p.next + i + 1) % len(p.proxies)
+		return candidate
+	}
+	return fallback
+}
+
+// report records the outcome of one page load through proxy
+func (p *ProxyPool) report(proxy *proxyState, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err == nil {
+		proxy.successes++
+		proxy.consecutive = 0
+		return
+	}
+	if !isProxyFailure(err) {
+		return
+	}
+
+	proxy.failures++
+	proxy.consecutive++
+	if proxy.consecutive >= p.banAfter {
+		proxy.bans++
+		proxy.consecutive = 0
+		proxy.bannedUntil = p.now().Add(p.banDuration)
+	}
+}
+
+// isProxyFailure reports errors that suggest the egress IP is the problem:
+// blocks, page timeouts and connection errors from Chrome's network stack.
+// A run or location budget running out says nothing about the proxy.
+func isProxyFailure(err error) bool {
+	var budget *BudgetError
+	if errors.As(err, &budget) {
+		return budget.Budget == BudgetSearchPage || budget.Budget == BudgetDetailPage
+	}
+	return errors.Is(err, ErrBlocked) || strings.Contains(err.Error(), "net::ERR_")
+}
+
+// WriteSummary prints the health of every proxy
+func (p *ProxyPool) WriteSummary(w io.Writer) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	fmt.Fprintln(w, "  Proxies:")
+	now := p.now()
+	for _, proxy := range p.proxies {
+		status := "ok"
+		if now.Before(proxy.bannedUntil) {
+			status = fmt.Sprintf("benched until %s", proxy.bannedUntil.Format("15:04:05"))
+		}
+		fmt.Fprintf(w, "    %-16s %d ok, %d failed, %d ban(s) - %s\n",
+			proxy.cfg.Name, proxy.successes, proxy.failures, proxy.bans, status)
+	}
+}
+
+// ProxyAssignment is the proxy a location currently uses; safe for concurrent use
+type ProxyAssignment struct {
+	pool     *ProxyPool
+	selector string
+
+	mu      sync.Mutex
+	current *proxyState
+}
+
+// Current returns the proxy new tabs should use, or nil for a direct connection
+func (a *ProxyAssignment) Current() *config.ProxyConfig {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.current == nil {
+		return nil
+	}
+	proxy := a.current.cfg
+	return &proxy
+}
+
+// Report records the outcome of a page load made through proxy, which must
+// be the value Current returned when the tab was opened
+func (a *ProxyAssignment) Report(proxy *config.ProxyConfig, err error) {
+	if a == nil || proxy == nil {
+		return
+	}
+	for _, state := range a.pool.proxies {
+		if state.cfg.Name == proxy.Name {
+			a.pool.report(state, err)
+			return
+		}
+	}
+}
+
+// Rotate moves the location off from after a block and reports whether tabs
+// opened through from should be reopened. When another worker has already
+// rotated away from it, the location keeps its new proxy, so concurrent
+// workers rotate once.
+func (a *ProxyAssignment) Rotate(from *config.ProxyConfig) bool {
+	if a == nil || from == nil {
+		return false
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.current == nil || a.current.cfg.Name != from.Name {
+		return true
+	}
+	next := a.pool.pick(a.selector, a.current)
+	if next == nil || next == a.current {
+		return false
+	}
+	a.current = next
+	return true
+}
+
+type proxyKey struct{}
+
+// WithProxy makes tabs opened from ctx use the assignment's current proxy
+func WithProxy(ctx context.Context, assignment *ProxyAssignment) context.Context {
+	return context.WithValue(ctx, proxyKey{}, assignment)
+}
+
+// proxyFrom returns the proxy for tabs opened from ctx, or nil for a direct connection
+func proxyFrom(ctx context.Context) *config.ProxyConfig {
+	assignment, _ := ctx.Value(proxyKey{}).(*ProxyAssignment)
+	return assignment.Current()
+}
+
+// locationProxySelectors maps each configured location slug to its proxy name or region
+func locationProxySelectors(cfg *config.Config) map[string]string {
+	selectors := make(map[string]string)
+	for _, loc := range cfg.Locations {
+		selectors[loc.Slug] = loc.Proxy
+	}
+	return selectors
+}
@@ -0,0 +1,88 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/emon51/rental-scraper/config"
+)
+
+// RateLimiter caps navigations per host with one token bucket each, shared by
+// every goroutine of a run, so the request rate does not grow with concurrency
+type RateLimiter struct {
+	limit rate.Limit
+	burst int
+
+	mu    sync.Mutex
+	hosts map[string]*rate.Limiter
+}
+
+// NewRateLimiter creates a limiter from config; 0 requests per second disables it
+func NewRateLimiter(cfg config.RateLimitConfig) *RateLimiter {
+	limit := rate.Limit(cfg.RequestsPerSecond)
+	if cfg.RequestsPerSecond <= 0 {
+		limit = rate.Inf
+	}
+	return &RateLimiter{
+		limit: limit,
+		burst: max(cfg.Burst, 1),
+		hosts: make(map[string]*rate.Limiter),
+	}
+}
+
+// Wait blocks until a request to the host of rawURL is allowed or ctx is done
+func (l *RateLimiter) Wait(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+
+	limiter := l.host(u.Host)
+	if limiter == nil {
+		return nil
+	}
+	return limiter.Wait(ctx)
+}
+
+// SetHostLimit slows host down to one request per interval, such as a
+// robots.txt Crawl-delay. A limit looser than the configured one is ignored.
+func (l *RateLimiter) SetHostLimit(host string, interval time.Duration) {
+	limit := rate.Every(interval)
+	if interval <= 0 || limit >= l.limit {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limiter, ok := l.hosts[host]
+	if !ok {
+		l.hosts[host] = rate.NewLimiter(limit, 1)
+		return
+	}
+	if limit < limiter.Limit() {
+		limiter.SetLimit(limit)
+		limiter.SetBurst(1)
+	}
+}
+
+// host returns the limiter for host, or nil when it is unlimited
+func (l *RateLimiter) host(host string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limiter, ok := l.hosts[host]
+	if !ok {
+		if l.limit == rate.Inf {
+			return nil
+		}
+		limiter = rate.NewLimiter(l.limit, l.burst)
+		l.hosts[host] = limiter
+	}
+	return limiter
+}
@@ -0,0 +1,212 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// snapshotTimeout bounds a DOM snapshot, which may be taken after the page's
+// own context is done
+const snapshotTimeout = 5 * time.Second
+
+// RecordingFetcher passes every call through to another Fetcher and writes
+// navigations, evaluation results, DOM snapshots and network responses to an archive.
+// The DOM of a page is snapshotted once, when the tab leaves it or closes,
+// so it holds the state the extraction saw. Entries are keyed by visit, so a page
+// visited more than once, such as in every window of a sweep, replays each visit.
+type RecordingFetcher struct {
+	inner   Fetcher
+	archive *ArchiveWriter
+
+	mu        sync.Mutex
+	main      recordingTab
+	visits    visitCounter
+	snapshots map[string]string // last snapshot written per visit, to skip duplicates
+}
+
+type recordingTab struct {
+	scope     string
+	current   pageVisit
+	observing bool
+	pending   bool // current has loaded and is not snapshotted yet
+}
+
+func NewRecordingFetcher(inner Fetcher, archive *ArchiveWriter) *RecordingFetcher {
+	return &RecordingFetcher{
+		inner:     inner,
+		archive:   archive,
+		visits:    make(visitCounter),
+		snapshots: make(map[string]string),
+	}
+}
+
+func (r *RecordingFetcher) NewTab(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	innerCtx, closeInner, err := r.inner.NewTab(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tabCtx, cancel := withTab(innerCtx, r, &recordingTab{scope: visitScopeFrom(ctx)})
+	return tabCtx, func() {
+		r.snapshot(tabCtx)
+		cancel()
+		closeInner()
+	}, nil
+}
+
+func (r *RecordingFetcher) Navigate(ctx context.Context, url string) error {
+	r.startObserving(ctx)
+	r.snapshot(ctx)
+
+	r.mu.Lock()
+	tab := tabFrom(ctx, r, &r.main)
+	visit := r.visits.next(tab.scope, url)
+	tab.current = visit
+	r.mu.Unlock()
+
+	err := r.inner.Navigate(ctx, url)
+
+	r.mu.Lock()
+	tabFrom(ctx, r, &r.main).pending = err == nil
+	r.mu.Unlock()
+
+	entry := visit.entry(entryNavigate)
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	r.writeEntry(entry)
+
+	return err
+}
+
+func (r *RecordingFetcher) Evaluate(ctx context.Context, script string, res interface{}) error {
+	var raw json.RawMessage
+	err := r.inner.Evaluate(ctx, script, &raw)
+
+	entry := r.currentVisit(ctx).entry(entryEvaluate)
+	entry.ScriptHash = hashScript(script)
+	entry.Result = raw
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	r.writeEntry(entry)
+
+	if err != nil {
+		return err
+	}
+
+	if res == nil {
+		return nil
+	}
+	return json.Unmarshal(raw, res)
+}
+
+// HTML returns the current DOM and records it as the page's snapshot
+func (r *RecordingFetcher) HTML(ctx context.Context) (string, error) {
+	html, err := r.inner.HTML(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	tab := tabFrom(ctx, r, &r.main)
+	tab.pending = false
+	visit := tab.current
+	r.mu.Unlock()
+
+	r.writeSnapshot(visit, html)
+	return html, nil
+}
+
+func (r *RecordingFetcher) Screenshot(ctx context.Context) ([]byte, error) {
+	return r.inner.Screenshot(ctx)
+}
+
+func (r *RecordingFetcher) BlockedRequests() map[string]int64 {
+	return BlockedRequests(r.inner)
+}
+
+// snapshot stores the DOM of the tab's current page, if it has not been stored
+// since the page loaded
+func (r *RecordingFetcher) snapshot(ctx context.Context) {
+	r.mu.Lock()
+	tab := tabFrom(ctx, r, &r.main)
+	pending, visit := tab.pending, tab.current
+	tab.pending = false
+	r.mu.Unlock()
+
+	if !pending {
+		return
+	}
+
+	// The page outlives a budget that ran out while it was open
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), snapshotTimeout)
+	defer cancel()
+
+	html, err := r.inner.HTML(ctx)
+	if err != nil {
+		fmt.Printf("  WARNING: failed to record snapshot of %s: %v\n", visit.url, err)
+		return
+	}
+	r.writeSnapshot(visit, html)
+}
+
+// writeSnapshot stores html for the visit unless it is unchanged since the visit's last snapshot
+func (r *RecordingFetcher) writeSnapshot(visit pageVisit, html string) {
+	r.mu.Lock()
+	unchanged := r.snapshots[visit.key()] == html
+	r.snapshots[visit.key()] = html
+	r.mu.Unlock()
+
+	if !unchanged {
+		entry := visit.entry(entrySnapshot)
+		entry.HTML = html
+		r.writeEntry(entry)
+	}
+}
+
+// startObserving subscribes to network responses once per tab, if the inner fetcher supports it
+func (r *RecordingFetcher) startObserving(ctx context.Context) {
+	observer, ok := r.inner.(ResponseObserver)
+	if !ok {
+		return
+	}
+
+	r.mu.Lock()
+	tab := tabFrom(ctx, r, &r.main)
+	if tab.observing {
+		r.mu.Unlock()
+		return
+	}
+	tab.observing = true
+	r.mu.Unlock()
+
+	err := observer.ObserveResponses(ctx, func(resp NetworkResponse) {
+		r.writeEntry(archiveEntry{
+			Kind:     entryResponse,
+			URL:      resp.URL,
+			PageURL:  r.currentVisit(ctx).url,
+			Status:   resp.Status,
+			MimeType: resp.MimeType,
+			Body:     resp.Body,
+		})
+	})
+	if err != nil {
+		fmt.Printf("  WARNING: network responses will not be recorded: %v\n", err)
+	}
+}
+
+func (r *RecordingFetcher) currentVisit(ctx context.Context) pageVisit {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return tabFrom(ctx, r, &r.main).current
+}
+
+func (r *RecordingFetcher) writeEntry(entry archiveEntry) {
+	if err := r.archive.write(entry); err != nil {
+		fmt.Printf("  WARNING: failed to record %s %s: %v\n", entry.Kind, entry.URL, err)
+	}
+}
@@ -0,0 +1,192 @@
+package scraper
+
+import (
+	"context"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// htmlCounter counts the DOM reads made through a FakeFetcher
+type htmlCounter struct {
+	*FakeFetcher
+	reads atomic.Int32
+}
+
+func (c *htmlCounter) HTML(ctx context.Context) (string, error) {
+	c.reads.Add(1)
+	return c.FakeFetcher.HTML(ctx)
+}
+
+func TestRecordingFetcherSnapshotsOncePerPage(t *testing.T) {
+	const first, second = "https://www.airbnb.com/s/Seoul/homes", "https://www.airbnb.com/rooms/1"
+	inner := &htmlCounter{FakeFetcher: NewFakeFetcher(map[string]FakePage{
+		first:  {HTML: "<html>search</html>", Result: true},
+		second: {HTML: "<html>room</html>", Result: true},
+	})}
+
+	path := filepath.Join(t.TempDir(), "session.jsonl.gz")
+	archive, err := CreateArchive(path, time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := NewRecordingFetcher(inner, archive)
+
+	ctx, closeTab, err := recorder.NewTab(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, url := range []string{first, second} {
+		if err := recorder.Navigate(ctx, url); err != nil {
+			t.Fatal(err)
+		}
+		// Readiness polls and extraction
+		for range 5 {
+			var ready bool
+			if err := recorder.Evaluate(ctx, "document.readyState === 'complete'", &ready); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	closeTab()
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := inner.reads.Load(); got != 2 {
+		t.Errorf("DOM read %d times, want once per page (2)", got)
+	}
+
+	loaded, err := LoadArchive(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.RunDate().Format(time.DateOnly); got != "2026-03-01" {
+		t.Errorf("run date = %s, want 2026-03-01", got)
+	}
+	replay := NewReplayFetcher(loaded)
+	replayCtx, closeReplay, err := replay.NewTab(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeReplay()
+
+	for url, want := range map[string]string{first: "<html>search</html>", second: "<html>room</html>"} {
+		if err := replay.Navigate(replayCtx, url); err != nil {
+			t.Fatal(err)
+		}
+		if html, err := replay.HTML(replayCtx); err != nil || html != want {
+			t.Errorf("replayed snapshot of %s = %q, %v; want %q", url, html, err, want)
+		}
+	}
+}
+
+func TestReplayKeepsEachVisit(t *testing.T) {
+	const room = "https://www.airbnb.com/rooms/1"
+	description := ""
+	inner := NewFakeFetcher(map[string]FakePage{
+		room: {EvaluateFunc: func(string) (interface{}, error) { return description, nil }},
+	})
+
+	path := filepath.Join(t.TempDir(), "session.jsonl.gz")
+	archive, err := CreateArchive(path, time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := NewRecordingFetcher(inner, archive)
+
+	// Two sweep windows open the same room, and the first visits it again
+	tabs := make(map[string]context.Context)
+	for _, scope := range []string{"Seoul/window-1", "Seoul/window-2"} {
+		ctx, closeTab, err := recorder.NewTab(withVisitScope(context.Background(), scope))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer closeTab()
+		tabs[scope] = ctx
+	}
+	visits := []struct{ scope, description string }{
+		{"Seoul/window-1", "first window"},
+		{"Seoul/window-2", "second window"},
+		{"Seoul/window-1", "first window, second visit"},
+	}
+	for _, v := range visits {
+		description = v.description
+		if err := recorder.Navigate(tabs[v.scope], room); err != nil {
+			t.Fatal(err)
+		}
+		var got string
+		if err := recorder.Evaluate(tabs[v.scope], "description", &got); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadArchive(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay := NewReplayFetcher(loaded)
+
+	// Replayed in another order, each window still sees its own visits
+	for _, i := range []int{1, 0, 2} {
+		v := visits[i]
+		ctx, closeTab, err := replay.NewTab(withVisitScope(context.Background(), v.scope))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := replay.Navigate(ctx, room); err != nil {
+			t.Fatal(err)
+		}
+		var got string
+		if err := replay.Evaluate(ctx, "description", &got); err != nil || got != v.description {
+			t.Errorf("replayed visit %d in %s = %q, %v; want %q", i+1, v.scope, got, err, v.description)
+		}
+		closeTab()
+	}
+}
+
+func TestReplayUnnumberedVisits(t *testing.T) {
+	const room = "https://www.airbnb.com/rooms/1"
+	path := filepath.Join(t.TempDir(), "session.jsonl.gz")
+	archive, err := CreateArchive(path, time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Archives recorded before visits were numbered
+	for _, entry := range []archiveEntry{
+		{Kind: entryNavigate, URL: room},
+		{Kind: entryEvaluate, URL: room, ScriptHash: hashScript("description"), Result: []byte(`"recorded"`)},
+	} {
+		if err := archive.write(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadArchive(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay := NewReplayFetcher(loaded)
+	ctx, closeTab, err := replay.NewTab(withVisitScope(context.Background(), "Seoul"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeTab()
+
+	for visit := 1; visit <= 2; visit++ {
+		if err := replay.Navigate(ctx, room); err != nil {
+			t.Fatal(err)
+		}
+		var got string
+		if err := replay.Evaluate(ctx, "description", &got); err != nil || got != "recorded" {
+			t.Errorf("visit %d = %q, %v; want the recorded description", visit, got, err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrNotRecorded is returned when a replayed session asks for something the archive lacks
+var ErrNotRecorded = errors.New("not in archive")
+
+// ReplayFetcher serves navigations and evaluation results from a recorded archive,
+// so a run reproduces the recorded listings without a browser or network.
+// Visits are numbered as they were when recording, so a page visited again
+// replays what that visit saw rather than the last one.
+type ReplayFetcher struct {
+	archive *Archive
+
+	mu     sync.Mutex
+	main   replayTab
+	visits visitCounter
+}
+
+type replayTab struct {
+	scope   string
+	current pageVisit
+}
+
+func NewReplayFetcher(archive *Archive) *ReplayFetcher {
+	return &ReplayFetcher{archive: archive, visits: make(visitCounter)}
+}
+
+func (r *ReplayFetcher) NewTab(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	tabCtx, cancel := withTab(ctx, r, &replayTab{scope: visitScopeFrom(ctx)})
+	return tabCtx, cancel, nil
+}
+
+func (r *ReplayFetcher) Navigate(ctx context.Context, url string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	tab := tabFrom(ctx, r, &r.main)
+	visit, ok := r.archive.recordedVisit(r.visits.next(tab.scope, url))
+	tab.current = visit
+	r.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("replay navigate %s: %w", url, ErrNotRecorded)
+	}
+	if entry := r.archive.navigations[visit.key()]; entry.Error != "" {
+		return fmt.Errorf("replay navigate %s: recorded error: %s", url, entry.Error)
+	}
+
+	return nil
+}
+
+func (r *ReplayFetcher) Evaluate(ctx context.Context, script string, res interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	visit := r.currentVisit(ctx)
+	entry, ok := r.archive.evaluations[evaluationKey(visit, hashScript(script))]
+	if !ok {
+		return fmt.Errorf("replay evaluate on %s: %w", visit.url, ErrNotRecorded)
+	}
+	if entry.Error != "" {
+		return fmt.Errorf("replay evaluate on %s: recorded error: %s", visit.url, entry.Error)
+	}
+
+	if res == nil {
+		return nil
+	}
+	return json.Unmarshal(entry.Result, res)
+}
+
+func (r *ReplayFetcher) HTML(ctx context.Context) (string, error) {
+	visit := r.currentVisit(ctx)
+	entry, ok := r.archive.snapshots[visit.key()]
+	if !ok {
+		return "", fmt.Errorf("replay snapshot of %s: %w", visit.url, ErrNotRecorded)
+	}
+	return entry.HTML, nil
+}
+
+// Screenshot is not available: archives hold the DOM, not rendered pages
+func (r *ReplayFetcher) Screenshot(ctx context.Context) ([]byte, error) {
+	return nil, ErrNoScreenshot
+}
+
+func (r *ReplayFetcher) currentVisit(ctx context.Context) pageVisit {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return tabFrom(ctx, r, &r.main).current
+}
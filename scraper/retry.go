@@ -0,0 +1,86 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/emon51/rental-scraper/config"
+	"github.com/emon51/rental-scraper/utils"
+)
+
+// retryPolicy is a resolved RetryConfig
+type retryPolicy struct {
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	jitter         float64
+}
+
+func newRetryPolicy(cfg config.RetryConfig) retryPolicy {
+	return retryPolicy{
+		maxAttempts:    max(cfg.MaxAttempts, 1),
+		initialBackoff: time.Duration(cfg.InitialBackoff) * time.Second,
+		maxBackoff:     time.Duration(cfg.MaxBackoff) * time.Second,
+		jitter:         cfg.Jitter,
+	}
+}
+
+// backoff returns the wait after the given failed attempt (1-based):
+// initial_backoff doubled per attempt, capped at max_backoff, then jittered
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	d := p.initialBackoff
+	for i := 1; i < attempt && d < p.maxBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, p.maxBackoff)
+
+	if p.jitter > 0 {
+		d = time.Duration(float64(d) * (1 - p.jitter + 2*p.jitter*rand.Float64()))
+	}
+	return d
+}
+
+// isRetryable reports whether another attempt could succeed. Nothing is retried
+// once ctx is done, since a wider budget ran out or the run was interrupted,
+// nor when the failure does not depend on the attempt (a missing recording, a
+// closed browser, a search with no results, selectors that no longer match, a
+// URL robots.txt disallows).
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	switch {
+	case errors.Is(err, context.Canceled),
+		errors.Is(err, ErrNotRecorded),
+		errors.Is(err, ErrNoResults),
+		errors.Is(err, ErrLayoutChanged),
+		errors.Is(err, ErrDisallowed),
+		errors.Is(err, utils.ErrPoolClosed):
+		return false
+	}
+	return true
+}
+
+// withRetry runs fetch until it succeeds, fails with a non-retryable error or
+// uses up max_attempts, and records the attempts for url in the run stats
+func (s *Scraper) withRetry(ctx context.Context, kind, url string, fetch func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fetch()
+		if err == nil || attempt >= s.retry.maxAttempts || !isRetryable(ctx, err) {
+			s.stats.record(kind, url, attempt, err)
+			return err
+		}
+
+		delay := s.retry.backoff(attempt)
+		fmt.Printf("    RETRY: %s attempt %d/%d failed: %v (next in %v)\n",
+			kind, attempt, s.retry.maxAttempts, err, delay.Round(100*time.Millisecond))
+
+		if sleepErr := sleep(ctx, delay); sleepErr != nil {
+			s.stats.record(kind, url, attempt, err)
+			return err
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package scraper
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emon51/rental-scraper/config"
+	"github.com/emon51/rental-scraper/utils"
+)
+
+// ErrDisallowed means robots.txt does not let us fetch the URL, so it was skipped
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+const (
+	robotsFetchTimeout = 10 * time.Second
+	robotsMaxSize      = 500 << 10 // RFC 9309 requires parsing at least 500 KiB
+	robotsRetryAfter   = time.Minute
+)
+
+// RobotsPolicy decides whether a URL may be fetched, from the robots.txt of its
+// host and the allow-list in config. Each robots.txt is fetched once per run,
+// through the proxy and with the user agent of the location that first needs it.
+type RobotsPolicy struct {
+	userAgent string
+	allow     []robotsRule
+	limiter   *RateLimiter
+	logger    *utils.Logger
+
+	mu    sync.Mutex
+	hosts map[string]*robotsEntry
+}
+
+// robotsEntry is the cached robots.txt of one host
+type robotsEntry struct {
+	ready   chan struct{} // Closed once rules or err is set
+	rules   robotsRules
+	err     error // Set when robots.txt could not be fetched; everything is disallowed
+	expires time.Time
+}
+
+// robotsRules are the rules of the groups that apply to our user agent
+type robotsRules struct {
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+	re      *regexp.Regexp
+}
+
+// NewRobotsPolicy creates the policy, or returns nil when robots.enabled is off.
+// Crawl-delay values are applied to limiter.
+func NewRobotsPolicy(cfg config.RobotsConfig, limiter *RateLimiter, logger *utils.Logger) *RobotsPolicy {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	p := &RobotsPolicy{
+		userAgent: cfg.UserAgent,
+		limiter:   limiter,
+		logger:    logger,
+		hosts:     make(map[string]*robotsEntry),
+	}
+	for _, pattern := range cfg.Allow {
+		p.allow = append(p.allow, newRobotsRule(true, pattern))
+	}
+	return p
+}
+
+// Check returns an ErrDisallowed error saying why rawURL may not be fetched,
+// or nil when it may. A nil policy allows everything.
+func (p *RobotsPolicy) Check(ctx context.Context, rawURL string) error {
+	if p == nil {
+		return nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+	target := u.EscapedPath()
+	if target == "" {
+		target = "/"
+	}
+	if u.RawQuery != "" {
+		target += "?" + u.RawQuery
+	}
+
+	for _, rule := range p.allow {
+		if rule.re.MatchString(target) {
+			return nil
+		}
+	}
+
+	entry, err := p.entry(ctx, u)
+	if err != nil {
+		return err
+	}
+	if entry.err != nil {
+		return fmt.Errorf("%w: %v", ErrDisallowed, entry.err)
+	}
+	if rule := entry.rules.match(target); rule != nil && !rule.allow {
+		return fmt.Errorf("%w: Disallow %s", ErrDisallowed, rule.pattern)
+	}
+	return nil
+}
+
+// entry returns the robots.txt of u's host, fetching it on first use. Other
+// callers wait for the fetch in progress rather than starting their own.
+func (p *RobotsPolicy) entry(ctx context.Context, u *url.URL) (*robotsEntry, error) {
+	key := u.Scheme + "://" + u.Host
+
+	p.mu.Lock()
+	entry, ok := p.hosts[key]
+	if ok && entry.err != nil && time.Now().After(entry.expires) {
+		ok = false
+	}
+	if !ok {
+		entry = &robotsEntry{ready: make(chan struct{})}
+		p.hosts[key] = entry
+		// Fetch on a context of its own, so one caller giving up does not fail the others
+		go p.load(context.WithoutCancel(ctx), key, u.Host, entry)
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-entry.ready:
+		return entry, nil
+	case <-ctx.Done():
+		return nil, context.Cause(ctx)
+	}
+}
+
+func (p *RobotsPolicy) load(ctx context.Context, origin, host string, entry *robotsEntry) {
+	defer close(entry.ready)
+
+	rules, err := p.fetch(ctx, origin+"/robots.txt")
+	if err != nil {
+		entry.err = err
+		entry.expires = time.Now().Add(robotsRetryAfter)
+		p.logger.Error(fmt.Sprintf("robots.txt for %s unavailable; skipping its pages for %s", host, robotsRetryAfter), err)
+		return
+	}
+
+	entry.rules = rules
+	if rules.crawlDelay > 0 {
+		p.limiter.SetHostLimit(host, rules.crawlDelay)
+		p.logger.Info(fmt.Sprintf("robots.txt for %s sets Crawl-delay %s", host, rules.crawlDelay))
+	}
+}
+
+// fetch downloads and parses a robots.txt like a page load from ctx: through
+// its proxy, as its profile, and paced by the rate limiter. As RFC 9309 says,
+// a missing file (any 4xx) allows everything, while a server error or an
+// unreachable host is an error, which disallows everything.
+func (p *RobotsPolicy) fetch(ctx context.Context, robotsURL string) (robotsRules, error) {
+	if err := p.limiter.Wait(ctx, robotsURL); err != nil {
+		return robotsRules{}, err
+	}
+
+	client, err := robotsClient(proxyFrom(ctx))
+	if err != nil {
+		return robotsRules{}, err
+	}
+	defer client.CloseIdleConnections()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return robotsRules{}, err
+	}
+	profile := profileFrom(ctx)
+	userAgent := config.DefaultUserAgent
+	if profile != nil && profile.UserAgent != "" {
+		userAgent = profile.UserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if profile != nil && profile.AcceptLanguage != "" {
+		req.Header.Set("Accept-Language", profile.AcceptLanguage)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return robotsRules{}, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return parseRobots(io.LimitReader(resp.Body, robotsMaxSize), p.userAgent), nil
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return robotsRules{}, nil
+	default:
+		return robotsRules{}, fmt.Errorf("%s returned %s", robotsURL, resp.Status)
+	}
+}
+
+// robotsClient returns an HTTP client that connects through proxy, or
+// directly when proxy is nil
+func robotsClient(proxy *config.ProxyConfig) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy != nil {
+		proxyURL, err := url.Parse(proxy.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL for proxy %s: %w", proxy.Name, err)
+		}
+		if proxy.Username != "" {
+			proxyURL.User = url.UserPassword(proxy.Username, proxy.Password.Reveal())
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	return &http.Client{Transport: transport, Timeout: robotsFetchTimeout}, nil
+}
+
+// parseRobots returns the rules of the groups naming userAgent, or of the '*'
+// groups when none does
+func parseRobots(r io.Reader, userAgent string) robotsRules {
+	var matched, wildcard robotsRules
+	var agents []string
+	named := false   // Some group names userAgent
+	inRules := false // A User-agent line after rules starts a new group
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		if key == "user-agent" {
+			if inRules {
+				agents, inRules = nil, false
+			}
+			agents = append(agents, strings.ToLower(value))
+			named = named || strings.EqualFold(value, userAgent)
+			continue
+		}
+		if key != "allow" && key != "disallow" && key != "crawl-delay" {
+			// Sitemap and unknown lines belong to no group
+			continue
+		}
+		inRules = true
+
+		var groups []*robotsRules
+		for _, agent := range agents {
+			switch agent {
+			case strings.ToLower(userAgent):
+				groups = append(groups, &matched)
+			case "*":
+				groups = append(groups, &wildcard)
+			}
+		}
+
+		for _, group := range groups {
+			switch key {
+			case "allow", "disallow":
+				if value != "" {
+					group.rules = append(group.rules, newRobotsRule(key == "allow", value))
+				}
+			case "crawl-delay":
+				if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+					group.crawlDelay = max(group.crawlDelay, time.Duration(seconds*float64(time.Second)))
+				}
+			}
+		}
+	}
+
+	if named {
+		return matched
+	}
+	return wildcard
+}
+
+// newRobotsRule compiles a robots.txt path pattern: '*' matches any run of
+// characters and a trailing '$' anchors the end
+func newRobotsRule(allow bool, pattern string) robotsRule {
+	anchored := strings.HasSuffix(pattern, "$")
+	expr := regexp.QuoteMeta(strings.TrimSuffix(pattern, "$"))
+	expr = "^" + strings.ReplaceAll(expr, `\*`, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return robotsRule{allow: allow, pattern: pattern, re: regexp.MustCompile(expr)}
+}
+
+// match returns the rule deciding target: the longest matching pattern, with
+// Allow winning a tie. nil means no rule matches and the URL is allowed.
+func (r robotsRules) match(target string) *robotsRule {
+	var best *robotsRule
+	for i := range r.rules {
+		rule := &r.rules[i]
+		if !rule.re.MatchString(target) {
+			continue
+		}
+		if best == nil || len(rule.pattern) > len(best.pattern) ||
+			(len(rule.pattern) == len(best.pattern) && rule.allow) {
+			best = rule
+		}
+	}
+	return best
+}
+
+// checkRobots consults the robots policy before a navigation and logs a skipped URL with the reason
+func (s *Scraper) checkRobots(ctx context.Context, url string) error {
+	err := s.robots.Check(ctx, url)
+	if errors.Is(err, ErrDisallowed) {
+		s.logger.Info(fmt.Sprintf("Skipped %s: %v", url, err))
+	}
+	return err
+}
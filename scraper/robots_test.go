@@ -0,0 +1,223 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRobotsGroups(t *testing.T) {
+	tests := []struct {
+		name       string
+		robots     string
+		allowed    []string
+		disallowed []string
+		crawlDelay time.Duration
+	}{
+		{
+			name: "named group replaces wildcard",
+			robots: `
+User-agent: *
+Disallow: /
+
+User-agent: rental-scraper
+Disallow: /rooms/
+`,
+			allowed:    []string{"/s/Seoul/homes"},
+			disallowed: []string{"/rooms/1"},
+		},
+		{
+			name: "wildcard when no group names us",
+			robots: `
+User-agent: otherbot
+Disallow: /
+
+User-agent: *
+Disallow: /account
+`,
+			allowed:    []string{"/", "/rooms/1"},
+			disallowed: []string{"/account/settings"},
+		},
+		{
+			name: "user agent matched case-insensitively",
+			robots: `
+User-agent: Rental-Scraper
+Disallow: /private
+`,
+			disallowed: []string{"/private"},
+		},
+		{
+			name: "consecutive user agents share a group",
+			robots: `
+User-agent: otherbot
+User-agent: rental-scraper
+Disallow: /wishlists
+Crawl-delay: 2
+`,
+			disallowed: []string{"/wishlists/1"},
+			crawlDelay: 2 * time.Second,
+		},
+		{
+			name: "groups naming us are merged",
+			robots: `
+User-agent: rental-scraper
+Disallow: /a
+
+User-agent: *
+Disallow: /b
+
+User-agent: rental-scraper
+Disallow: /c
+Crawl-delay: 0.5
+`,
+			allowed:    []string{"/b"},
+			disallowed: []string{"/a", "/c"},
+			crawlDelay: 500 * time.Millisecond,
+		},
+		{
+			name: "sitemap does not split a group",
+			robots: `
+User-agent: rental-scraper
+Sitemap: https://www.example.com/sitemap.xml
+User-agent: otherbot
+Disallow: /rooms/
+`,
+			disallowed: []string{"/rooms/1"},
+		},
+		{
+			name: "comments and empty disallow ignored",
+			robots: `
+# Everything is allowed
+User-agent: * # all robots
+Disallow:
+`,
+			allowed: []string{"/", "/rooms/1"},
+		},
+		{
+			name:    "empty file allows everything",
+			robots:  "",
+			allowed: []string{"/", "/rooms/1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := parseRobots(strings.NewReader(tt.robots), "rental-scraper")
+
+			for _, target := range tt.allowed {
+				if rule := rules.match(target); rule != nil && !rule.allow {
+					t.Errorf("%s disallowed by %q, want allowed", target, rule.pattern)
+				}
+			}
+			for _, target := range tt.disallowed {
+				if rule := rules.match(target); rule == nil || rule.allow {
+					t.Errorf("%s allowed, want disallowed", target)
+				}
+			}
+			if rules.crawlDelay != tt.crawlDelay {
+				t.Errorf("crawl delay = %v, want %v", rules.crawlDelay, tt.crawlDelay)
+			}
+		})
+	}
+}
+
+func TestRobotsRulesMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		rules  []robotsRule
+		target string
+		want   string // Pattern of the deciding rule, "" for none
+		allow  bool
+	}{
+		{
+			name:   "longest match wins",
+			rules:  []robotsRule{newRobotsRule(false, "/rooms"), newRobotsRule(true, "/rooms/plus")},
+			target: "/rooms/plus/1",
+			want:   "/rooms/plus",
+			allow:  true,
+		},
+		{
+			name:   "longer disallow beats allow",
+			rules:  []robotsRule{newRobotsRule(true, "/"), newRobotsRule(false, "/rooms/")},
+			target: "/rooms/1",
+			want:   "/rooms/",
+		},
+		{
+			name:   "allow wins a tie",
+			rules:  []robotsRule{newRobotsRule(false, "/page"), newRobotsRule(true, "/page")},
+			target: "/page",
+			want:   "/page",
+			allow:  true,
+		},
+		{
+			name:   "allow wins a tie in either order",
+			rules:  []robotsRule{newRobotsRule(true, "/page"), newRobotsRule(false, "/page")},
+			target: "/page",
+			want:   "/page",
+			allow:  true,
+		},
+		{
+			name:   "no rule matches",
+			rules:  []robotsRule{newRobotsRule(false, "/rooms/")},
+			target: "/s/Seoul/homes",
+		},
+		{
+			name:   "prefix match",
+			rules:  []robotsRule{newRobotsRule(false, "/s/")},
+			target: "/s/Seoul/homes?adults=2",
+			want:   "/s/",
+		},
+		{
+			name:   "wildcard in the middle",
+			rules:  []robotsRule{newRobotsRule(false, "/rooms/*/photos")},
+			target: "/rooms/123/photos/4",
+			want:   "/rooms/*/photos",
+		},
+		{
+			name:   "wildcard matches an empty run",
+			rules:  []robotsRule{newRobotsRule(false, "/*.json")},
+			target: "/.json",
+			want:   "/*.json",
+		},
+		{
+			name:   "dollar anchors the end",
+			rules:  []robotsRule{newRobotsRule(false, "/*.pdf$")},
+			target: "/guide.pdf",
+			want:   "/*.pdf$",
+		},
+		{
+			name:   "dollar rejects a longer path",
+			rules:  []robotsRule{newRobotsRule(false, "/*.pdf$")},
+			target: "/guide.pdf?download=1",
+		},
+		{
+			name:   "query string is part of the target",
+			rules:  []robotsRule{newRobotsRule(false, "/*?cursor=")},
+			target: "/s/Seoul/homes?cursor=abc",
+			want:   "/*?cursor=",
+		},
+		{
+			name:   "regexp characters are literal",
+			rules:  []robotsRule{newRobotsRule(false, "/a.b")},
+			target: "/axb",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := robotsRules{rules: tt.rules}.match(tt.target)
+			if tt.want == "" {
+				if rule != nil {
+					t.Fatalf("match(%q) = %q, want no rule", tt.target, rule.pattern)
+				}
+				return
+			}
+			if rule == nil {
+				t.Fatalf("match(%q) = no rule, want %q", tt.target, tt.want)
+			}
+			if rule.pattern != tt.want || rule.allow != tt.allow {
+				t.Errorf("match(%q) = %q (allow %v), want %q (allow %v)", tt.target, rule.pattern, rule.allow, tt.want, tt.allow)
+			}
+		})
+	}
+}
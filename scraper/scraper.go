@@ -2,127 +2,352 @@ package scraper
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/chromedp/chromedp"
 	"github.com/emon51/rental-scraper/config"
 	"github.com/emon51/rental-scraper/models"
+	"github.com/emon51/rental-scraper/utils"
 )
 
 type Scraper struct {
-	baseURL              string
-	listingsPerPage      int
-	pagesToScrape        int
-	requestDelay         int
-	descriptionConfig    config.DescriptionFetchConfig
+	fetcher           Fetcher
+	baseURL           string
+	listingsPerPage   int
+	pagesToScrape     int // 0 = no page limit
+	maxListings       int // Per location; 0 = no limit
+	descriptionConfig config.DescriptionFetchConfig
+	timeouts          config.TimeoutsConfig
+	retry             retryPolicy
+	stats             *Stats
+	limiter           *RateLimiter
+	robots            *RobotsPolicy
+	debug             *DebugDumper
+	logger            *utils.Logger
+	profiles          map[string]*config.ProfileConfig // by location slug
+	proxies           *ProxyPool
+	proxySelectors    map[string]string              // location slug -> proxy name or region
+	searches          map[string]models.SearchParams // by location slug
+	searchWait        pageWait
+	descriptionWait   pageWait
 }
 
-func NewScraper(baseURL string, listingsPerPage, pagesToScrape, requestDelay int, descConfig config.DescriptionFetchConfig) *Scraper {
+// NewScraper creates a scraper that loads pages through fetcher
+func NewScraper(cfg *config.Config, logger *utils.Logger, fetcher Fetcher) *Scraper {
+	limiter := NewRateLimiter(cfg.RateLimit)
 	return &Scraper{
-		baseURL:           baseURL,
-		listingsPerPage:   listingsPerPage,
-		pagesToScrape:     pagesToScrape,
-		requestDelay:      requestDelay,
-		descriptionConfig: descConfig,
+		fetcher:           fetcher,
+		baseURL:           cfg.BaseURL,
+		listingsPerPage:   cfg.ListingsPerPage,
+		pagesToScrape:     cfg.PagesToScrape,
+		maxListings:       cfg.MaxListings,
+		descriptionConfig: cfg.DescriptionConfig,
+		timeouts:          cfg.Timeouts,
+		retry:             newRetryPolicy(cfg.Retry),
+		stats:             &Stats{},
+		limiter:           limiter,
+		robots:            NewRobotsPolicy(cfg.Robots, limiter, logger),
+		debug:             NewDebugDumper(cfg.Debug.Dir, time.Now()),
+		logger:            logger,
+		profiles:          locationProfiles(cfg),
+		proxies:           NewProxyPool(cfg),
+		proxySelectors:    locationProxySelectors(cfg),
+		searches:          locationSearches(cfg, cfg.Today()),
+		searchWait:        newPageWait(cfg.Waits.Search, ItemListSelector, cfg.ListingsPerPage),
+		descriptionWait:   newPageWait(cfg.Waits.Description, DescriptionSelector, 1),
 	}
 }
 
-// ScrapeLocation scrapes multiple pages from a location
+// ProxyPool returns the pool of proxies, or nil when none are configured
+func (s *Scraper) ProxyPool() *ProxyPool {
+	return s.proxies
+}
+
+// Stats returns the per-page outcomes of every location scraped so far
+func (s *Scraper) Stats() *Stats {
+	return s.stats
+}
+
+// ScrapeLocation scrapes multiple pages from a location within the location budget.
+// If a budget runs out part way, the listings collected so far are returned.
 func (s *Scraper) ScrapeLocation(ctx context.Context, locationSlug, displayName string) ([]models.Listing, error) {
+	return s.scrapeLocation(ctx, locationSlug, displayName, s.searches[locationSlug])
+}
+
+// ScrapeWindow scrapes a location like ScrapeLocation, searching for the stay
+// window's dates, and tags the listings with the window
+func (s *Scraper) ScrapeWindow(ctx context.Context, locationSlug, displayName string, window Window) ([]models.Listing, error) {
+	search := s.searches[locationSlug]
+	search.CheckIn = window.CheckIn.Format(time.DateOnly)
+	search.CheckOut = window.CheckOut.Format(time.DateOnly)
+	search.Window = window.Index
+	return s.scrapeLocation(ctx, locationSlug, displayName, search)
+}
+
+func (s *Scraper) scrapeLocation(ctx context.Context, locationSlug, displayName string, search models.SearchParams) ([]models.Listing, error) {
+	ctx, cancel := WithBudget(ctx, BudgetLocation, s.timeouts.Location, displayName)
+	defer cancel()
+
+	// Windows of a sweep open the same listings; recordings keep their visits apart
+	scope := locationSlug
+	if search.Window > 0 {
+		scope = fmt.Sprintf("%s/window-%d", locationSlug, search.Window)
+	}
+	ctx = withVisitScope(ctx, scope)
+
+	if profile := s.profiles[locationSlug]; profile != nil {
+		fmt.Printf("  [%s] Browser profile: %s\n", displayName, profile.Name)
+		ctx = WithProfile(ctx, profile)
+	}
+
+	if search != (models.SearchParams{}) {
+		fmt.Printf("  [%s] Search: %s\n", displayName, describeSearch(search))
+	}
+
+	if assignment := s.proxies.Assign(s.proxySelectors[locationSlug]); assignment != nil {
+		fmt.Printf("  [%s] Proxy: %s\n", displayName, proxyLabel(assignment.Current()))
+		ctx = WithProxy(ctx, assignment)
+	}
+
+	allListings, err := s.scrapeSearchPages(ctx, locationSlug, displayName, search)
+	if err != nil {
+		return nil, explainTimeout(ctx, err)
+	}
+
+	// Fetch descriptions concurrently
+	fmt.Printf("  Fetching descriptions concurrently for %s...\n", displayName)
+	s.fetchDescriptionsConcurrently(ctx, locationSlug, allListings)
+
+	if err := explainTimeout(ctx, ctx.Err()); err != nil {
+		fmt.Printf("  WARNING: %s stopped early: %v\n", displayName, err)
+	}
+
+	return allListings, nil
+}
+
+// scrapeSearchPages collects listings from the search pages of a location.
+// The pages load in a tab of their own, released before descriptions are fetched.
+// When no page yields listings, the first page's failure is returned.
+func (s *Scraper) scrapeSearchPages(ctx context.Context, locationSlug, displayName string, search models.SearchParams) ([]models.Listing, error) {
 	var allListings []models.Listing
+	var firstErr error
 
-	for page := 1; page <= s.pagesToScrape; page++ {
-		offset := (page - 1) * AirbnbPageOffset
+	tab, err := s.openLocationTab(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tab.Close()
+
+	// Pages seen so far by URL and by content, to stop when the site loops back
+	visited := make(map[string]int)
+	fingerprints := make(map[string]int)
 
-		url := s.buildURL(locationSlug, offset)
+	url := s.buildURL(locationSlug, search)
+	for page := 1; s.pagesToScrape == 0 || page <= s.pagesToScrape; page++ {
+		if earlier, ok := visited[url]; ok {
+			fmt.Printf("  Page %d of %s links back to page %d; stopping\n", page, displayName, earlier)
+			break
+		}
+		visited[url] = page
 
 		fmt.Printf("  [%s] Page %d: Fetching %d listings...\n", displayName, page, s.listingsPerPage)
 
-		listings, err := s.fetchListingsFromPage(ctx, url)
+		var listings []models.Listing
+		var next string
+		err := s.withRetry(ctx, KindSearchPage, url, func() error {
+			var err error
+			listings, next, err = s.fetchListingsFromPage(tab.ctx, url)
+			tab.settle(err)
+			return err
+		})
+		// The last page of results is normally short, so only a short page with more to follow is suspect
+		short := len(listings) < s.listingsPerPage && next != ""
+		if (err != nil || short) && !errors.Is(err, ErrDisallowed) {
+			reason := fmt.Sprintf("only %d of %d listings extracted", len(listings), s.listingsPerPage)
+			if err != nil {
+				reason = err.Error()
+			}
+			s.dumpPage(tab.ctx, searchDumpName(locationSlug, search.Window, page), url, reason)
+		}
+
 		if err != nil {
-			fmt.Printf("  WARNING: Failed page %d for %s: %v\n", page, displayName, err)
-			continue
+			if firstErr == nil {
+				firstErr = fmt.Errorf("page %d: %w", page, err)
+			}
+			switch {
+			case errors.Is(err, ErrNoResults):
+				fmt.Printf("  No results on page %d of %s\n", page, displayName)
+			case errors.Is(err, ErrDisallowed):
+				// Already logged as skipped
+			default:
+				// Without the page there is no link to the next one
+				fmt.Printf("  WARNING: Failed page %d for %s: %v\n", page, displayName, err)
+			}
+			break
 		}
 
+		fingerprint := pageFingerprint(listings)
+		if earlier, ok := fingerprints[fingerprint]; ok {
+			fmt.Printf("  Page %d of %s repeats page %d; stopping\n", page, displayName, earlier)
+			break
+		}
+		fingerprints[fingerprint] = page
+
 		fmt.Printf("  Found %d listings on page %d of %s\n", len(listings), page, displayName)
 
 		// Set metadata
-		s.setListingMetadata(listings, displayName)
+		s.setListingMetadata(listings, displayName, search)
 
 		allListings = append(allListings, listings...)
 
-		// Pause between pages
-		if page < s.pagesToScrape {
-			time.Sleep(3 * time.Second)
+		if s.maxListings > 0 && len(allListings) >= s.maxListings {
+			allListings = allListings[:s.maxListings]
+			fmt.Printf("  Reached %d listings for %s\n", s.maxListings, displayName)
+			break
+		}
+		if next == "" {
+			fmt.Printf("  Last page of results for %s: page %d\n", displayName, page)
+			break
 		}
+		url = next
 	}
 
-	// Fetch descriptions concurrently
-	fmt.Printf("  Fetching descriptions concurrently for %s...\n", displayName)
-	s.fetchDescriptionsConcurrently(ctx, allListings)
-
+	if len(allListings) == 0 && firstErr != nil {
+		return nil, firstErr
+	}
 	return allListings, nil
 }
 
-// buildURL constructs the Airbnb search URL with pagination
-func (s *Scraper) buildURL(locationSlug string, offset int) string {
-	url := fmt.Sprintf(s.baseURL, locationSlug)
-	if offset > 0 {
-		url = fmt.Sprintf("%s?items_offset=%d", url, offset)
+// buildURL constructs the URL of the first Airbnb search page with search parameters;
+// later pages come from the page's own next-page link
+func (s *Scraper) buildURL(locationSlug string, search models.SearchParams) string {
+	searchURL := fmt.Sprintf(s.baseURL, locationSlug)
+	query := searchQuery(search)
+	if len(query) > 0 {
+		searchURL += "?" + query.Encode()
 	}
-	return url
+	return searchURL
 }
 
-// fetchListingsFromPage extracts listings from a single page
-func (s *Scraper) fetchListingsFromPage(ctx context.Context, url string) ([]models.Listing, error) {
+// fetchListingsFromPage extracts listings, and the URL of the next page if
+// there is one, from a single page within the search page budget
+func (s *Scraper) fetchListingsFromPage(ctx context.Context, url string) ([]models.Listing, string, error) {
 	var listings []models.Listing
 
-	err := chromedp.Run(ctx,
-		chromedp.Navigate(url),
-		chromedp.Sleep(12*time.Second),
-		chromedp.Evaluate(s.getExtractionScript(), &listings),
-	)
+	if err := s.checkRobots(ctx, url); err != nil {
+		return nil, "", explainTimeout(ctx, err)
+	}
 
-	return listings, err
+	// Waiting for the rate limiter does not count against the page budget
+	if err := s.limiter.Wait(ctx, url); err != nil {
+		return nil, "", explainTimeout(ctx, err)
+	}
+
+	pageCtx, cancel := WithBudget(ctx, BudgetSearchPage, s.timeouts.SearchPage, url)
+	defer cancel()
+
+	if err := s.fetcher.Navigate(pageCtx, url); err != nil {
+		return nil, "", explainTimeout(pageCtx, err)
+	}
+
+	if err := s.waitForPage(pageCtx, s.searchWait, url); err != nil {
+		return nil, "", explainTimeout(pageCtx, err)
+	}
+
+	// Prefer the page's structured data; the DOM heuristics misread prices and ratings
+	data, err := s.extractFromPageData(pageCtx, url, s.listingsPerPage)
+	if err != nil {
+		return nil, "", explainTimeout(pageCtx, err)
+	}
+	listings = data.Listings
+
+	if len(listings) == 0 {
+		s.logger.Info(fmt.Sprintf("No structured listing data on %s; reading the page's cards", url))
+		err = s.fetcher.Evaluate(pageCtx, s.getExtractionScript(), &listings)
+		if err == nil && len(listings) == 0 {
+			err = s.explainEmptySearch(pageCtx)
+		}
+		if err != nil {
+			return listings, "", explainTimeout(pageCtx, err)
+		}
+	}
+
+	next := s.nextPageURL(pageCtx, url)
+	if next == "" && data.NextCursor != "" {
+		if next, err = withCursor(url, data.NextCursor); err != nil {
+			return listings, "", err
+		}
+	}
+	return listings, next, nil
 }
 
-// setListingMetadata adds platform and location to listings
-func (s *Scraper) setListingMetadata(listings []models.Listing, location string) {
+// setListingMetadata adds platform, location and search parameters to listings
+func (s *Scraper) setListingMetadata(listings []models.Listing, location string, search models.SearchParams) {
 	for i := range listings {
 		listings[i].Platform = "Airbnb"
 		listings[i].Location = location
+		listings[i].Search = search
 	}
 }
 
-// fetchDescriptionsConcurrently fetches descriptions in parallel with rate limiting
-func (s *Scraper) fetchDescriptionsConcurrently(ctx context.Context, listings []models.Listing) {
-	var wg sync.WaitGroup
-	semaphore := make(chan struct{}, s.descriptionConfig.MaxConcurrent)
-
+// fetchDescriptionsConcurrently fetches descriptions in parallel; the shared rate limiter paces them.
+// Each worker owns one tab for its whole life, so navigations never interleave.
+func (s *Scraper) fetchDescriptionsConcurrently(ctx context.Context, locationSlug string, listings []models.Listing) {
+	jobs := make(chan int, len(listings))
 	for i := range listings {
-		if listings[i].URL == "" {
-			continue
+		if listings[i].URL != "" {
+			jobs <- i
 		}
+	}
+	close(jobs)
 
+	workers := s.descriptionConfig.MaxConcurrent
+	if len(jobs) < workers {
+		workers = len(jobs)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
 		wg.Add(1)
 
-		go func(index int) {
+		go func() {
 			defer wg.Done()
 
-			// Acquire semaphore
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
-
-			fmt.Printf("    [%d/%d] Fetching description...\n", index+1, len(listings))
-
-			listings[index].Description = s.getDescription(ctx, listings[index].URL)
-
-			// Rate limiting
-			time.Sleep(time.Duration(s.requestDelay) * time.Second)
-		}(i)
+			tab, err := s.openLocationTab(ctx)
+			if err != nil {
+				fmt.Printf("    WARNING: failed to open description tab: %v\n", err)
+				return
+			}
+			defer tab.Close()
+
+			for index := range jobs {
+				fmt.Printf("    [%d/%d] Fetching description...\n", index+1, len(listings))
+
+				url := listings[index].URL
+				err := s.withRetry(ctx, KindDetailPage, url, func() error {
+					description, err := s.getDescription(tab.ctx, url)
+					listings[index].Description = description
+					tab.settle(err)
+					return err
+				})
+				if errors.Is(err, ErrDisallowed) {
+					continue
+				}
+				if err != nil {
+					fmt.Printf("    WARNING: No description for %s: %v\n", url, err)
+				}
+				if listings[index].Description == "" {
+					reason := "empty description"
+					if err != nil {
+						reason = err.Error()
+					}
+					s.dumpPage(tab.ctx, listingDumpName(locationSlug, listings[index].Search.Window, url), url, reason)
+				}
+			}
+		}()
 	}
 
 	wg.Wait()
@@ -133,25 +358,69 @@ func (s *Scraper) getExtractionScript() string {
 	return fmt.Sprintf(ExtractionScriptTemplate, s.listingsPerPage)
 }
 
-// getDescription fetches description from a listing detail page
-func (s *Scraper) getDescription(ctx context.Context, url string) string {
-	// Create timeout context for description fetch
-	descCtx, cancel := context.WithTimeout(ctx, time.Duration(s.descriptionConfig.Timeout)*time.Second)
+// getDescription fetches description from a listing detail page within the detail page budget
+func (s *Scraper) getDescription(ctx context.Context, url string) (string, error) {
+	if err := s.checkRobots(ctx, url); err != nil {
+		return "", explainTimeout(ctx, err)
+	}
+
+	if err := s.limiter.Wait(ctx, url); err != nil {
+		return "", explainTimeout(ctx, err)
+	}
+
+	descCtx, cancel := WithBudget(ctx, BudgetDetailPage, s.timeouts.DetailPage, url)
 	defer cancel()
 
 	var description string
 
-	err := chromedp.Run(descCtx,
-		chromedp.Navigate(url),
-		chromedp.Sleep(5*time.Second),
-		chromedp.Evaluate(fmt.Sprintf(`
+	if err := s.fetcher.Navigate(descCtx, url); err != nil {
+		return "", explainTimeout(descCtx, err)
+	}
+
+	if err := s.waitForPage(descCtx, s.descriptionWait, url); err != nil {
+		return "", explainTimeout(descCtx, err)
+	}
+
+	err := s.fetcher.Evaluate(descCtx, fmt.Sprintf(`
 			document.querySelector('%s')?.innerText || ''
-		`, DescriptionSelector), &description),
-	)
+		`, DescriptionSelector), &description)
+
+	if err != nil {
+		return "", explainTimeout(descCtx, err)
+	}
+
+	description = strings.TrimSpace(description)
+	if description == "" {
+		return "", explainTimeout(descCtx, s.explainEmptyDescription(descCtx))
+	}
 
+	return description, nil
+}
+
+// dumpPage saves a screenshot and the HTML of the page in the tab when debug
+// dumps are enabled, and logs where they went
+func (s *Scraper) dumpPage(tabCtx context.Context, name, url, reason string) {
+	if s.debug == nil || tabCtx.Err() != nil {
+		return
+	}
+
+	files, err := s.debug.Dump(tabCtx, s.fetcher, name)
 	if err != nil {
-		return ""
+		s.logger.Error(fmt.Sprintf("Debug dump of %s incomplete (%s)", url, strings.Join(files, ", ")), err)
+		return
 	}
+	s.logger.Info(fmt.Sprintf("%s: %s; debug dump: %s", url, reason, strings.Join(files, ", ")))
+}
+
+// sleep pauses for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
 
-	return strings.TrimSpace(description)
-}
\ No newline at end of file
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
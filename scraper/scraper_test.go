@@ -0,0 +1,212 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/emon51/rental-scraper/config"
+	"github.com/emon51/rental-scraper/models"
+	"github.com/emon51/rental-scraper/utils"
+)
+
+const testSearchURL = "https://www.airbnb.com/s/Seoul/homes"
+
+// newTestScraper returns a scraper over fetcher that neither waits, backs off
+// nor consults robots.txt
+func newTestScraper(t *testing.T, fetcher Fetcher) *Scraper {
+	t.Helper()
+
+	cfg := config.NewConfig()
+	cfg.BaseURL = "https://www.airbnb.com/s/%s/homes"
+	cfg.ListingsPerPage = 2
+	cfg.PagesToScrape = 0
+	cfg.Robots.Enabled = false
+	cfg.RateLimit.RequestsPerSecond = 0
+	cfg.Retry = config.RetryConfig{MaxAttempts: 3}
+	cfg.Waits.Search = config.WaitConfig{Strategy: config.WaitSleep, Fallback: config.WaitNone}
+	cfg.Waits.Description = cfg.Waits.Search
+
+	logger, err := utils.NewLogger(filepath.Join(t.TempDir(), "scraper.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { logger.Close() })
+
+	return NewScraper(cfg, logger, fetcher)
+}
+
+// searchPage is a search page listing rooms, linking to next when it is not empty
+func searchPage(next string, rooms ...int) FakePage {
+	var items []map[string]interface{}
+	for _, room := range rooms {
+		items = append(items, map[string]interface{}{
+			"item": map[string]string{
+				"name": fmt.Sprintf("Room %d", room),
+				"url":  fmt.Sprintf("https://www.airbnb.com/rooms/%d", room),
+			},
+		})
+	}
+	jsonLD, _ := json.Marshal(map[string]interface{}{"@type": "ItemList", "itemListElement": items})
+
+	return FakePage{EvaluateFunc: func(script string) (interface{}, error) {
+		switch script {
+		case PageDataScript:
+			return pageData{JSONLD: []string{string(jsonLD)}}, nil
+		case NextPageScript:
+			return next, nil
+		}
+		return nil, nil
+	}}
+}
+
+// emptyPage is a search page whose cards yield nothing and whose probe reports probe
+func emptyPage(probe pageProbe) FakePage {
+	return FakePage{EvaluateFunc: func(script string) (interface{}, error) {
+		switch script {
+		case PageDataScript:
+			return pageData{}, nil
+		case PageProbeScript:
+			return probe, nil
+		}
+		return []models.Listing{}, nil
+	}}
+}
+
+func TestScrapeSearchPagesPagination(t *testing.T) {
+	page2 := testSearchURL + "?cursor=2"
+	page3 := testSearchURL + "?cursor=3"
+
+	tests := []struct {
+		name    string
+		pages   map[string]FakePage
+		limit   int // max_listings
+		visited []string
+		rooms   int
+	}{
+		{
+			name: "last page",
+			pages: map[string]FakePage{
+				testSearchURL: searchPage(page2, 1, 2),
+				page2:         searchPage("", 3),
+			},
+			visited: []string{testSearchURL, page2},
+			rooms:   3,
+		},
+		{
+			name: "repeated page",
+			pages: map[string]FakePage{
+				testSearchURL: searchPage(page2, 1, 2),
+				page2:         searchPage(page3, 3, 4),
+				page3:         searchPage(testSearchURL+"?cursor=4", 2, 1),
+			},
+			visited: []string{testSearchURL, page2, page3},
+			rooms:   4,
+		},
+		{
+			name: "link back to a visited page",
+			pages: map[string]FakePage{
+				testSearchURL: searchPage(page2, 1, 2),
+				page2:         searchPage(testSearchURL, 3, 4),
+			},
+			visited: []string{testSearchURL, page2},
+			rooms:   4,
+		},
+		{
+			name: "max listings",
+			pages: map[string]FakePage{
+				testSearchURL: searchPage(page2, 1, 2),
+				page2:         searchPage(page3, 3, 4),
+				page3:         searchPage("", 5, 6),
+			},
+			limit:   3,
+			visited: []string{testSearchURL, page2},
+			rooms:   3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := NewFakeFetcher(tt.pages)
+			s := newTestScraper(t, fetcher)
+			s.maxListings = tt.limit
+
+			listings, err := s.scrapeSearchPages(context.Background(), "Seoul", "Seoul", models.SearchParams{})
+			if err != nil {
+				t.Fatalf("scrapeSearchPages() error = %v", err)
+			}
+			if len(listings) != tt.rooms {
+				t.Errorf("got %d listings, want %d", len(listings), tt.rooms)
+			}
+			if got := fetcher.Visited(); !reflect.DeepEqual(got, tt.visited) {
+				t.Errorf("visited %v, want %v", got, tt.visited)
+			}
+		})
+	}
+}
+
+func TestScrapeSearchPagesRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		page     FakePage
+		want     error // nil to only check the attempts
+		attempts int
+	}{
+		{
+			name:     "connection error retried",
+			page:     FakePage{Err: errors.New("page load error net::ERR_CONNECTION_RESET")},
+			attempts: 3,
+		},
+		{
+			name:     "block page retried",
+			page:     emptyPage(pageProbe{Captcha: true}),
+			want:     ErrBlocked,
+			attempts: 3,
+		},
+		{
+			name:     "page without listings or no-results message retried",
+			page:     emptyPage(pageProbe{}),
+			want:     ErrIncomplete,
+			attempts: 3,
+		},
+		{
+			name:     "no results not retried",
+			page:     emptyPage(pageProbe{NoResults: true}),
+			want:     ErrNoResults,
+			attempts: 1,
+		},
+		{
+			name:     "layout change not retried",
+			page:     emptyPage(pageProbe{Cards: 0, ListingLinks: 5}),
+			want:     ErrLayoutChanged,
+			attempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := NewFakeFetcher(map[string]FakePage{testSearchURL: tt.page})
+			s := newTestScraper(t, fetcher)
+
+			_, err := s.scrapeSearchPages(context.Background(), "Seoul", "Seoul", models.SearchParams{})
+			if err == nil {
+				t.Fatal("scrapeSearchPages() succeeded, want an error")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("scrapeSearchPages() error = %v, want %v", err, tt.want)
+			}
+			if got := len(fetcher.Visited()); got != tt.attempts {
+				t.Errorf("page loaded %d times, want %d", got, tt.attempts)
+			}
+
+			pages := s.Stats().Pages()
+			if len(pages) != 1 || pages[0].Attempts != tt.attempts {
+				t.Errorf("stats = %+v, want one page with %d attempts", pages, tt.attempts)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package scraper
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emon51/rental-scraper/config"
+	"github.com/emon51/rental-scraper/models"
+)
+
+// airbnbRoomTypes maps config.RoomTypes to Airbnb's room_types[] values
+var airbnbRoomTypes = map[string]string{
+	"entire_home":  "Entire home/apt",
+	"private_room": "Private room",
+	"shared_room":  "Shared room",
+	"hotel_room":   "Hotel room",
+}
+
+// airbnbPropertyTypes maps config.PropertyTypes to Airbnb's l2_property_type_ids[] values
+var airbnbPropertyTypes = map[string]string{
+	"house":      "1",
+	"guesthouse": "2",
+	"apartment":  "3",
+	"hotel":      "4",
+}
+
+// locationSearches resolves each configured location's search, with relative
+// dates counted from today, so every page of a run asks for the same dates
+func locationSearches(cfg *config.Config, today time.Time) map[string]models.SearchParams {
+	searches := make(map[string]models.SearchParams)
+	for i, loc := range cfg.Locations {
+		searches[loc.Slug] = resolveSearch(cfg.LocationSearch(i), today)
+	}
+	return searches
+}
+
+// resolveSearch turns a validated SearchConfig into the parameters stored with listings
+func resolveSearch(search config.SearchConfig, today time.Time) models.SearchParams {
+	params := models.SearchParams{
+		Adults:       search.Adults,
+		Children:     search.Children,
+		PriceMin:     search.PriceMin,
+		PriceMax:     search.PriceMax,
+		RoomType:     search.RoomType,
+		PropertyType: search.PropertyType,
+	}
+	if checkIn, err := config.ResolveDate(search.CheckIn, today); err == nil && !checkIn.IsZero() {
+		params.CheckIn = checkIn.Format(time.DateOnly)
+	}
+	if checkOut, err := config.ResolveDate(search.CheckOut, today); err == nil && !checkOut.IsZero() {
+		params.CheckOut = checkOut.Format(time.DateOnly)
+	}
+	return params
+}
+
+// searchQuery returns the Airbnb search query parameters for params
+func searchQuery(params models.SearchParams) url.Values {
+	query := url.Values{}
+	if params.CheckIn != "" {
+		query.Set("checkin", params.CheckIn)
+		query.Set("checkout", params.CheckOut)
+	}
+	if params.Adults > 0 {
+		query.Set("adults", strconv.Itoa(params.Adults))
+	}
+	if params.Children > 0 {
+		query.Set("children", strconv.Itoa(params.Children))
+	}
+	if params.PriceMin > 0 {
+		query.Set("price_min", strconv.Itoa(params.PriceMin))
+	}
+	if params.PriceMax > 0 {
+		query.Set("price_max", strconv.Itoa(params.PriceMax))
+	}
+	if roomType, ok := airbnbRoomTypes[params.RoomType]; ok {
+		query.Set("room_types[]", roomType)
+	}
+	if propertyType, ok := airbnbPropertyTypes[params.PropertyType]; ok {
+		query.Set("l2_property_type_ids[]", propertyType)
+	}
+	return query
+}
+
+// describeSearch summarizes params for progress output, e.g.
+// "2026-11-15 to 2026-11-17, 2 adults, price 50-200, entire_home"
+func describeSearch(params models.SearchParams) string {
+	var parts []string
+	if params.Window > 0 {
+		parts = append(parts, fmt.Sprintf("window %d", params.Window))
+	}
+	if params.CheckIn != "" {
+		parts = append(parts, fmt.Sprintf("%s to %s", params.CheckIn, params.CheckOut))
+	}
+	if params.Adults > 0 {
+		parts = append(parts, fmt.Sprintf("%d adults", params.Adults))
+	}
+	if params.Children > 0 {
+		parts = append(parts, fmt.Sprintf("%d children", params.Children))
+	}
+	if params.PriceMin > 0 || params.PriceMax > 0 {
+		price := fmt.Sprintf("price %d-", params.PriceMin)
+		if params.PriceMax > 0 {
+			price += strconv.Itoa(params.PriceMax)
+		}
+		parts = append(parts, price)
+	}
+	for _, filter := range []string{params.RoomType, params.PropertyType} {
+		if filter != "" {
+			parts = append(parts, filter)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
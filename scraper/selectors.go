@@ -6,15 +6,25 @@ const (
 	ItemListSelector     = "[itemprop=\"itemListElement\"]"
 	ListingLinkSelector  = "a[href*=\"/rooms/\"]"
 	ListingTitleSelector = "[data-testid=\"listing-card-name\"]"
-	
+
 	// Description selector
 	DescriptionSelector = "[data-section-id=\"DESCRIPTION_DEFAULT\"]"
-	
-	// Pagination offset multiplier
-	AirbnbPageOffset = 20
 )
 
-// JavaScript extraction template
+// PageDataScript returns the raw text of the blocks holding a page's structured
+// data: Airbnb's data-deferred-state JSON and any JSON-LD
+const PageDataScript = `
+	(() => {
+		const texts = (selector) => Array.from(document.querySelectorAll(selector)).map(el => el.textContent);
+		return {
+			deferred_state: texts('script[id^="data-deferred-state"]'),
+			json_ld: texts('script[type="application/ld+json"]')
+		};
+	})()
+`
+
+// ExtractionScriptTemplate reads listing cards from the DOM. It is the fallback
+// for pages without structured data, guessing price and rating from card text.
 const ExtractionScriptTemplate = `
 	(() => {
 		const cards = Array.from(document.querySelectorAll('[itemprop="itemListElement"]')).slice(0, %d);
@@ -54,4 +64,33 @@ const ExtractionScriptTemplate = `
 			};
 		});
 	})()
-`
\ No newline at end of file
+`
+
+// NextPageScript returns the absolute URL of the next search page, whose
+// query carries the cursor for that page, or an empty string on the last one
+const NextPageScript = `
+	(() => {
+		const next = document.querySelector('nav[aria-label*="pagination" i] a[aria-label="Next"], a[aria-label="Next"][href], link[rel="next"]');
+		return next && next.href && next.getAttribute('aria-disabled') !== 'true' ? next.href : '';
+	})()
+`
+
+// PageProbeScript reports the signals used to explain a page that yielded nothing
+const PageProbeScript = `
+	(() => {
+		const text = (document.body ? document.body.innerText : '').slice(0, 20000).toLowerCase();
+		const has = (selector) => document.querySelector(selector) !== null;
+
+		return {
+			url: location.href,
+			title: document.title,
+			captcha: has('iframe[src*="captcha"], #px-captcha, [id*="captcha"], [class*="captcha"], iframe[src*="hcaptcha"], iframe[src*="recaptcha"]') ||
+				text.includes('verify you are a human') || text.includes('press & hold') || text.includes('are you a robot'),
+			blocked: /access denied|forbidden|too many requests|unusual traffic|request blocked/.test(document.title.toLowerCase() + ' ' + text.slice(0, 2000)),
+			login: /^\/(login|signup_login|authenticate)/.test(location.pathname),
+			no_results: text.includes('no exact matches') || text.includes('no results') || text.includes('try adjusting your search'),
+			cards: document.querySelectorAll('[itemprop="itemListElement"]').length,
+			listing_links: document.querySelectorAll('a[href*="/rooms/"]').length
+		};
+	})()
+`
@@ -0,0 +1,104 @@
+package scraper
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Kinds of page tracked in the run stats
+const (
+	KindSearchPage = "search page"
+	KindDetailPage = "detail page"
+)
+
+// PageAttempts is the outcome of fetching one URL
+type PageAttempts struct {
+	Kind     string
+	URL      string
+	Attempts int
+	Err      error // Last error, nil when the page was fetched
+}
+
+// Stats collects per-page outcomes over a run; safe for concurrent use
+type Stats struct {
+	mu    sync.Mutex
+	pages []PageAttempts
+}
+
+func (st *Stats) record(kind, url string, attempts int, err error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.pages = append(st.pages, PageAttempts{Kind: kind, URL: url, Attempts: attempts, Err: err})
+}
+
+// Pages returns a copy of every recorded outcome, in the order they finished
+func (st *Stats) Pages() []PageAttempts {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	return append([]PageAttempts(nil), st.pages...)
+}
+
+// WriteSummary prints totals per kind of page, then every URL that needed
+// more than one attempt or failed, most attempts first
+func (st *Stats) WriteSummary(w io.Writer) {
+	pages := st.Pages()
+
+	for _, kind := range []string{KindSearchPage, KindDetailPage} {
+		var total, failed, retries int
+		reasons := make(map[string]int)
+		for _, p := range pages {
+			if p.Kind != kind {
+				continue
+			}
+			total++
+			retries += p.Attempts - 1
+			if p.Err != nil {
+				failed++
+				reasons[FailureReason(p.Err)]++
+			}
+		}
+		fmt.Fprintf(w, "  %-12s %d fetched, %d failed, %d retries%s\n", kind+"s:", total-failed, failed, retries, formatReasons(reasons))
+	}
+
+	var notable []PageAttempts
+	for _, p := range pages {
+		if p.Attempts > 1 || p.Err != nil {
+			notable = append(notable, p)
+		}
+	}
+	if len(notable) == 0 {
+		return
+	}
+
+	sort.SliceStable(notable, func(i, j int) bool {
+		return notable[i].Attempts > notable[j].Attempts
+	})
+
+	fmt.Fprintln(w, "  Retried or failed pages:")
+	for _, p := range notable {
+		outcome := "ok"
+		if p.Err != nil {
+			outcome = "FAILED: " + p.Err.Error()
+		}
+		fmt.Fprintf(w, "    %d attempt(s) %s %s - %s\n", p.Attempts, p.Kind, p.URL, outcome)
+	}
+}
+
+// formatReasons renders failure counts as " (2 blocked, 1 timeout)" in a fixed order
+func formatReasons(counts map[string]int) string {
+	var parts []string
+	for _, reason := range FailureReasons {
+		if counts[reason] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[reason], reason))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
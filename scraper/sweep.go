@@ -0,0 +1,47 @@
+package scraper
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/emon51/rental-scraper/config"
+)
+
+// Window is one stay of a date sweep
+type Window struct {
+	Index    int // From 1
+	CheckIn  time.Time
+	CheckOut time.Time
+}
+
+func (w Window) String() string {
+	return fmt.Sprintf("%s to %s", w.CheckIn.Format(time.DateOnly), w.CheckOut.Format(time.DateOnly))
+}
+
+// SweepWindows expands a validated sweep into its stay windows, counting
+// relative dates from today. It returns nil when the sweep is off.
+func SweepWindows(cfg config.SweepConfig, today time.Time) []Window {
+	if !cfg.Enabled() {
+		return nil
+	}
+	first, err := cfg.FirstCheckIn(today)
+	if err != nil {
+		return nil
+	}
+
+	every := cfg.Every
+	if every == 0 {
+		every = cfg.Nights
+	}
+
+	windows := make([]Window, cfg.Windows)
+	for i := range windows {
+		checkIn := first.AddDate(0, 0, i*every)
+		windows[i] = Window{
+			Index:    i + 1,
+			CheckIn:  checkIn,
+			CheckOut: checkIn.AddDate(0, 0, cfg.Nights),
+		}
+	}
+	return windows
+}
@@ -0,0 +1,115 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/emon51/rental-scraper/config"
+)
+
+const (
+	// waitPollInterval is how often readiness conditions are re-checked
+	waitPollInterval = 250 * time.Millisecond
+
+	// networkIdleWindow is how long the resource count must stay flat to count as idle
+	networkIdleWindow = 500 * time.Millisecond
+)
+
+// errWaitTimeout marks a strategy that ran out of time without the page becoming ready
+var errWaitTimeout = errors.New("wait timed out")
+
+// pageWait is a resolved WaitConfig for one kind of page
+type pageWait struct {
+	cfg      config.WaitConfig
+	selector string
+	minCount int
+}
+
+func newPageWait(cfg config.WaitConfig, defaultSelector string, defaultMinCount int) pageWait {
+	w := pageWait{cfg: cfg, selector: cfg.Selector, minCount: cfg.MinCount}
+	if w.selector == "" {
+		w.selector = defaultSelector
+	}
+	if w.minCount == 0 {
+		w.minCount = defaultMinCount
+	}
+	return w
+}
+
+// waitForPage blocks until the page in ctx is ready using the configured strategy
+// and, if that times out, the fallback. A page that never becomes ready is still
+// extracted, so only cancellation of ctx itself is returned as an error.
+func (s *Scraper) waitForPage(ctx context.Context, w pageWait, url string) error {
+	err := s.runWaitStrategy(ctx, w, w.cfg.Strategy, time.Duration(w.cfg.Timeout)*time.Second)
+	if err == nil || ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if w.cfg.Fallback == "" || w.cfg.Fallback == config.WaitNone {
+		fmt.Printf("  WARNING: %s wait failed for %s (%v); extracting anyway\n", w.cfg.Strategy, url, err)
+		return nil
+	}
+
+	fallbackErr := s.runWaitStrategy(ctx, w, w.cfg.Fallback, time.Duration(w.cfg.FallbackTimeout)*time.Second)
+	if fallbackErr != nil && ctx.Err() == nil {
+		fmt.Printf("  WARNING: %s and %s waits failed for %s (%v); extracting anyway\n", w.cfg.Strategy, w.cfg.Fallback, url, fallbackErr)
+	}
+
+	return ctx.Err()
+}
+
+func (s *Scraper) runWaitStrategy(ctx context.Context, w pageWait, strategy string, timeout time.Duration) error {
+	switch strategy {
+	case config.WaitSleep:
+		return sleep(ctx, timeout)
+	case config.WaitSelector:
+		// Polled through Evaluate, so min_count applies and a recorded session replays the wait
+		script := fmt.Sprintf(`document.querySelectorAll(%s).length`, jsString(w.selector))
+		return s.pollUntil(ctx, timeout, script, func(count int) bool {
+			return count >= w.minCount
+		})
+	case config.WaitNetworkIdle:
+		script := `document.readyState === 'complete' ? performance.getEntriesByType('resource').length : -1`
+		last, stableSince := -1, time.Time{}
+		return s.pollUntil(ctx, timeout, script, func(count int) bool {
+			if count < 0 || count != last {
+				last, stableSince = count, time.Now()
+				return false
+			}
+			return time.Since(stableSince) >= networkIdleWindow
+		})
+	default:
+		return fmt.Errorf("unknown wait strategy %q", strategy)
+	}
+}
+
+// pollUntil evaluates script every waitPollInterval until ready accepts its result
+func (s *Scraper) pollUntil(ctx context.Context, timeout time.Duration, script string, ready func(int) bool) error {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		var value int
+		if err := s.fetcher.Evaluate(ctx, script, &value); err != nil {
+			return err
+		}
+		if ready(value) {
+			return nil
+		}
+
+		if time.Now().Add(waitPollInterval).After(deadline) {
+			return errWaitTimeout
+		}
+		if err := sleep(ctx, waitPollInterval); err != nil {
+			return err
+		}
+	}
+}
+
+// jsString quotes s as a JavaScript string literal
+func jsString(s string) string {
+	quoted, _ := json.Marshal(s)
+	return string(quoted)
+}
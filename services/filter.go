@@ -19,8 +19,9 @@ func (f *Filter) CleanListings(listings []models.Listing) []models.Listing {
 	seen := make(map[string]bool)
 
 	for _, listing := range listings {
-		// Skip if URL is empty or duplicate
-		if listing.URL == "" || seen[listing.URL] {
+		// Skip if URL is empty or duplicate; a date sweep keeps one per stay window
+		key := fmt.Sprintf("%s#%d", listing.URL, listing.Search.Window)
+		if listing.URL == "" || seen[key] {
 			continue
 		}
 
@@ -33,7 +34,7 @@ func (f *Filter) CleanListings(listings []models.Listing) []models.Listing {
 		listing = f.cleanListing(listing)
 
 		cleaned = append(cleaned, listing)
-		seen[listing.URL] = true
+		seen[key] = true
 	}
 
 	return cleaned
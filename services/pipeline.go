@@ -6,28 +6,56 @@ import (
 
 	"github.com/emon51/rental-scraper/config"
 	"github.com/emon51/rental-scraper/models"
+	"github.com/emon51/rental-scraper/scraper"
 	"github.com/emon51/rental-scraper/storage"
 	"github.com/emon51/rental-scraper/utils"
 )
 
+// Sinks selects where the pipeline sends scraped listings
+type Sinks struct {
+	CSVPath  string // CSV output file, empty to skip
+	JSONPath string // JSON output file, empty to skip
+	Database bool   // Insert into PostgreSQL
+	Insights bool   // Print the market insights report
+}
+
+// DefaultSinks writes CSV and PostgreSQL and prints insights
+func DefaultSinks() Sinks {
+	return Sinks{
+		CSVPath:  "listings.csv",
+		Database: true,
+		Insights: true,
+	}
+}
+
 type Pipeline struct {
-	cfg    *config.Config
-	logger *utils.Logger
+	cfg     *config.Config
+	logger  *utils.Logger
+	fetcher scraper.Fetcher
+	sinks   Sinks
 }
 
-func NewPipeline(cfg *config.Config, logger *utils.Logger) *Pipeline {
+func NewPipeline(cfg *config.Config, logger *utils.Logger, fetcher scraper.Fetcher) *Pipeline {
 	return &Pipeline{
-		cfg:    cfg,
-		logger: logger,
+		cfg:     cfg,
+		logger:  logger,
+		fetcher: fetcher,
+		sinks:   DefaultSinks(),
 	}
 }
 
+// WithSinks replaces the default sinks
+func (p *Pipeline) WithSinks(sinks Sinks) *Pipeline {
+	p.sinks = sinks
+	return p
+}
+
 // Execute runs the complete scraping pipeline
 func (p *Pipeline) Execute(ctx context.Context) error {
 	p.logger.Info("Pipeline execution started")
 
 	// Step 1: Scrape data
-	scraperService := NewScraperService(p.cfg, p.logger)
+	scraperService := NewScraperService(p.cfg, p.logger, p.fetcher)
 	cleanedListings, err := scraperService.ScrapeAll(ctx)
 	if err != nil {
 		p.logger.Error("Scraping failed", err)
@@ -35,21 +63,34 @@ func (p *Pipeline) Execute(ctx context.Context) error {
 	}
 	p.logger.Success(fmt.Sprintf("Scraped %d listings", len(cleanedListings)))
 
-	// Step 2: Save to CSV
-	if err := p.saveToCSV(cleanedListings); err != nil {
-		p.logger.Error("CSV save failed", err)
-		return fmt.Errorf("CSV save failed: %w", err)
+	// Step 2: Save to files
+	if p.sinks.CSVPath != "" {
+		if err := p.saveToCSV(cleanedListings); err != nil {
+			p.logger.Error("CSV save failed", err)
+			return fmt.Errorf("CSV save failed: %w", err)
+		}
+	}
+
+	if p.sinks.JSONPath != "" {
+		if err := p.saveToJSON(cleanedListings); err != nil {
+			p.logger.Error("JSON save failed", err)
+			return fmt.Errorf("JSON save failed: %w", err)
+		}
 	}
 
 	// Step 3: Save to PostgreSQL
-	if err := p.saveToDatabase(cleanedListings); err != nil {
-		p.logger.Error("Database save failed", err)
-		return fmt.Errorf("database save failed: %w", err)
+	if p.sinks.Database {
+		if err := p.saveToDatabase(cleanedListings); err != nil {
+			p.logger.Error("Database save failed", err)
+			return fmt.Errorf("database save failed: %w", err)
+		}
 	}
 
 	// Step 4: Generate insights
-	p.generateInsights(cleanedListings)
-	p.logger.Success("Insights generated successfully")
+	if p.sinks.Insights {
+		p.generateInsights(cleanedListings)
+		p.logger.Success("Insights generated successfully")
+	}
 
 	return nil
 }
@@ -58,27 +99,35 @@ func (p *Pipeline) saveToCSV(listings []models.Listing) error {
 	fmt.Println("\n=== STEP 3: SAVING TO CSV ===")
 	p.logger.Info("Saving listings to CSV")
 
-	csvWriter := storage.NewCSVWriter("listings.csv")
+	csvWriter := storage.NewCSVWriter(p.sinks.CSVPath)
 	if err := csvWriter.WriteListings(listings); err != nil {
 		return err
 	}
 
-	fmt.Println("✓ Data saved to listings.csv")
+	fmt.Printf("✓ Data saved to %s\n", p.sinks.CSVPath)
 	p.logger.Success("CSV file saved successfully")
 	return nil
 }
 
+func (p *Pipeline) saveToJSON(listings []models.Listing) error {
+	fmt.Println("\n=== STEP 3: SAVING TO JSON ===")
+	p.logger.Info("Saving listings to JSON")
+
+	jsonWriter := storage.NewJSONWriter(p.sinks.JSONPath)
+	if err := jsonWriter.WriteListings(listings); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Data saved to %s\n", p.sinks.JSONPath)
+	p.logger.Success("JSON file saved successfully")
+	return nil
+}
+
 func (p *Pipeline) saveToDatabase(listings []models.Listing) error {
 	fmt.Println("\n=== STEP 4: SAVING TO POSTGRESQL ===")
 	p.logger.Info("Connecting to PostgreSQL")
 
-	pgWriter, err := storage.NewPostgresWriter(
-		p.cfg.DBConfig.Host,
-		p.cfg.DBConfig.Port,
-		p.cfg.DBConfig.User,
-		p.cfg.DBConfig.Password,
-		p.cfg.DBConfig.DBName,
-	)
+	pgWriter, err := OpenDatabase(p.cfg)
 	if err != nil {
 		return fmt.Errorf("connection failed: %w", err)
 	}
@@ -106,4 +155,15 @@ func (p *Pipeline) generateInsights(listings []models.Listing) {
 	insightGen := NewInsightGenerator()
 	insights := insightGen.Generate(listings)
 	insightGen.PrintReport(insights)
-}
\ No newline at end of file
+}
+
+// OpenDatabase connects to PostgreSQL using the configured credentials
+func OpenDatabase(cfg *config.Config) (*storage.PostgresWriter, error) {
+	return storage.NewPostgresWriter(
+		cfg.DBConfig.Host,
+		cfg.DBConfig.Port,
+		cfg.DBConfig.User,
+		cfg.DBConfig.Password.Reveal(),
+		cfg.DBConfig.DBName,
+	)
+}
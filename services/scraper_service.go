@@ -2,7 +2,11 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/emon51/rental-scraper/config"
@@ -12,32 +16,65 @@ import (
 )
 
 type ScraperService struct {
-	cfg    *config.Config
-	logger *utils.Logger
+	cfg     *config.Config
+	logger  *utils.Logger
+	fetcher scraper.Fetcher
 }
 
-func NewScraperService(cfg *config.Config, logger *utils.Logger) *ScraperService {
+func NewScraperService(cfg *config.Config, logger *utils.Logger, fetcher scraper.Fetcher) *ScraperService {
 	return &ScraperService{
-		cfg:    cfg,
-		logger: logger,
+		cfg:     cfg,
+		logger:  logger,
+		fetcher: fetcher,
 	}
 }
 
+// scrapeJob is one search to run: a location, or one stay window of it in sweep mode
+type scrapeJob struct {
+	location config.LocationConfig
+	window   *scraper.Window
+}
+
+func (j scrapeJob) String() string {
+	if j.window == nil {
+		return j.location.DisplayName
+	}
+	return fmt.Sprintf("%s (window %d: %s)", j.location.DisplayName, j.window.Index, j.window)
+}
+
+// scrapeJobs expands the locations into the searches to run: one per location,
+// or one per location and stay window when the date sweep is on
+func (ss *ScraperService) scrapeJobs() []scrapeJob {
+	windows := scraper.SweepWindows(ss.cfg.Sweep, ss.cfg.Today())
+
+	var jobs []scrapeJob
+	for _, loc := range ss.cfg.Locations {
+		if len(windows) == 0 {
+			jobs = append(jobs, scrapeJob{location: loc})
+			continue
+		}
+		for i := range windows {
+			jobs = append(jobs, scrapeJob{location: loc, window: &windows[i]})
+		}
+	}
+	return jobs
+}
+
 // ScrapeAll collects listings from all configured locations concurrently
 func (ss *ScraperService) ScrapeAll(ctx context.Context) ([]models.Listing, error) {
 	fmt.Println("\n=== STEP 1: SCRAPING (CONCURRENT) ===")
-	ss.logger.Info(fmt.Sprintf("Starting concurrent scraping for %d locations", len(ss.cfg.Locations)))
+	jobs := ss.scrapeJobs()
+	if ss.cfg.Sweep.Enabled() {
+		ss.logger.Info(fmt.Sprintf("Starting date sweep of %d locations over %d stay windows (%d searches)",
+			len(ss.cfg.Locations), ss.cfg.Sweep.Windows, len(jobs)))
+	} else {
+		ss.logger.Info(fmt.Sprintf("Starting concurrent scraping for %d locations", len(ss.cfg.Locations)))
+	}
 
-	s := scraper.NewScraper(
-		ss.cfg.BaseURL,
-		ss.cfg.ListingsPerPage,
-		ss.cfg.PagesToScrape,
-		ss.cfg.RequestDelay,
-		ss.cfg.DescriptionConfig,
-	)
+	s := scraper.NewScraper(ss.cfg, ss.logger, ss.fetcher)
 
 	// Channel to collect listings
-	listingsChan := make(chan []models.Listing, len(ss.cfg.Locations))
+	listingsChan := make(chan []models.Listing, len(jobs))
 
 	// WaitGroup to wait for all goroutines
 	var wg sync.WaitGroup
@@ -45,32 +82,53 @@ func (ss *ScraperService) ScrapeAll(ctx context.Context) ([]models.Listing, erro
 	// Semaphore to limit concurrent scrapers
 	semaphore := make(chan struct{}, ss.cfg.MaxConcurrent)
 
-	// Launch goroutines for each location
-	for i, loc := range ss.cfg.Locations {
+	// Locations that yielded nothing, by scraper.FailureReason
+	var failuresMu sync.Mutex
+	locationFailures := make(map[string]int)
+
+	// Launch goroutines for each location, or each stay window of a location
+	for i, job := range jobs {
 		wg.Add(1)
 
-		go func(index int, location config.LocationConfig) {
+		go func(index int, job scrapeJob) {
 			defer wg.Done()
 
 			// Acquire semaphore
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
 
-			fmt.Printf("\n[%d/%d] Scraping: %s\n", index+1, len(ss.cfg.Locations), location.DisplayName)
-			ss.logger.Info(fmt.Sprintf("Scraping location: %s", location.DisplayName))
+			location := job.location
+			fmt.Printf("\n[%d/%d] Scraping: %s\n", index+1, len(jobs), job)
+			ss.logger.Info(fmt.Sprintf("Scraping location: %s", job))
 
-			listings, err := s.ScrapeLocation(ctx, location.Slug, location.DisplayName)
+			var listings []models.Listing
+			var err error
+			if job.window != nil {
+				listings, err = s.ScrapeWindow(ctx, location.Slug, location.DisplayName, *job.window)
+			} else {
+				listings, err = s.ScrapeLocation(ctx, location.Slug, location.DisplayName)
+			}
 			if err != nil {
-				fmt.Printf("  WARNING: Failed to scrape %s: %v\n", location.DisplayName, err)
-				ss.logger.Error(fmt.Sprintf("Failed to scrape %s", location.DisplayName), err)
+				reason := scraper.FailureReason(err)
+				failuresMu.Lock()
+				locationFailures[reason]++
+				failuresMu.Unlock()
+
+				if errors.Is(err, scraper.ErrNoResults) {
+					fmt.Printf("  No listings found for %s\n", job)
+					ss.logger.Info(fmt.Sprintf("No listings found for %s", job))
+				} else {
+					fmt.Printf("  WARNING: Failed to scrape %s (%s): %v\n", job, reason, err)
+					ss.logger.Error(fmt.Sprintf("Failed to scrape %s (%s)", job, reason), err)
+				}
 				listingsChan <- []models.Listing{}
 				return
 			}
 
-			fmt.Printf("✓ Collected %d listings from %s\n", len(listings), location.DisplayName)
-			ss.logger.Success(fmt.Sprintf("Scraped %d listings from %s", len(listings), location.DisplayName))
+			fmt.Printf("✓ Collected %d listings from %s\n", len(listings), job)
+			ss.logger.Success(fmt.Sprintf("Scraped %d listings from %s", len(listings), job))
 			listingsChan <- listings
-		}(i, loc)
+		}(i, job)
 	}
 
 	// Close channel when all goroutines complete
@@ -85,18 +143,80 @@ func (ss *ScraperService) ScrapeAll(ctx context.Context) ([]models.Listing, erro
 		allListings = append(allListings, listings...)
 	}
 
+	// Partial results are still cleaned and saved when the run is cut short
+	if ctx.Err() != nil {
+		cause := context.Cause(ctx)
+		fmt.Printf("\n  WARNING: Scraping stopped early: %v\n", cause)
+		ss.logger.Error("Scraping stopped early", cause)
+	}
+
+	fmt.Println("\nRun summary:")
+	s.Stats().WriteSummary(os.Stdout)
+	ss.reportBlockedRequests()
+	s.ProxyPool().WriteSummary(os.Stdout)
+	ss.logRetries(s.Stats())
+	ss.reportLocationFailures(locationFailures)
+
 	fmt.Printf("\nRaw listings scraped: %d\n", len(allListings))
 	ss.logger.Info(fmt.Sprintf("Total raw listings scraped: %d", len(allListings)))
 
 	// Step 2: Clean data
 	fmt.Println("\n=== STEP 2: FILTERING & CLEANING ===")
 	ss.logger.Info("Starting data cleaning and filtering")
-	
+
 	filter := NewFilter()
 	cleaned := filter.CleanListings(allListings)
-	
+
 	fmt.Printf("Cleaned listings: %d\n", len(cleaned))
 	ss.logger.Success(fmt.Sprintf("Cleaned listings: %d (removed %d)", len(cleaned), len(allListings)-len(cleaned)))
 
 	return cleaned, nil
-}
\ No newline at end of file
+}
+
+// logRetries writes the pages that needed retries or failed to the log file
+func (ss *ScraperService) logRetries(stats *scraper.Stats) {
+	for _, p := range stats.Pages() {
+		switch {
+		case p.Err != nil:
+			ss.logger.Error(fmt.Sprintf("%s %s failed after %d attempt(s)", p.Kind, p.URL, p.Attempts), p.Err)
+		case p.Attempts > 1:
+			ss.logger.Info(fmt.Sprintf("%s %s fetched after %d attempts", p.Kind, p.URL, p.Attempts))
+		}
+	}
+}
+
+// reportLocationFailures prints and logs how many locations yielded nothing, per reason
+func (ss *ScraperService) reportLocationFailures(failures map[string]int) {
+	for _, reason := range scraper.FailureReasons {
+		if failures[reason] == 0 {
+			continue
+		}
+		fmt.Printf("  Locations without listings (%s): %d\n", reason, failures[reason])
+		ss.logger.Info(fmt.Sprintf("Locations without listings (%s): %d", reason, failures[reason]))
+	}
+}
+
+// reportBlockedRequests prints and logs how many requests the browser blocked, by resource type
+func (ss *ScraperService) reportBlockedRequests() {
+	blocked := scraper.BlockedRequests(ss.fetcher)
+	if len(blocked) == 0 {
+		return
+	}
+
+	kinds := make([]string, 0, len(blocked))
+	var total int64
+	for kind, n := range blocked {
+		kinds = append(kinds, kind)
+		total += n
+	}
+	sort.Strings(kinds)
+
+	parts := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		parts = append(parts, fmt.Sprintf("%d %s", blocked[kind], kind))
+	}
+
+	msg := fmt.Sprintf("Blocked requests: %d (%s)", total, strings.Join(parts, ", "))
+	fmt.Printf("  %s\n", msg)
+	ss.logger.Info(msg)
+}
@@ -3,6 +3,7 @@ package storage
 import (
 	"encoding/csv"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/emon51/rental-scraper/models"
@@ -27,7 +28,8 @@ func (w *CSVWriter) WriteListings(listings []models.Listing) error {
 	defer writer.Flush()
 
 	// Write header
-	header := []string{"Platform", "Title", "Price", "Location", "Rating", "URL", "Description"}
+	header := []string{"Platform", "Title", "Price", "Location", "Rating", "URL", "Description",
+		"CheckIn", "CheckOut", "Adults", "Children", "PriceMin", "PriceMax", "RoomType", "PropertyType", "Window"}
 	if err := writer.Write(header); err != nil {
 		return err
 	}
@@ -42,6 +44,15 @@ func (w *CSVWriter) WriteListings(listings []models.Listing) error {
 			listing.Rating,
 			listing.URL,
 			listing.Description,
+			listing.Search.CheckIn,
+			listing.Search.CheckOut,
+			optionalInt(listing.Search.Adults),
+			optionalInt(listing.Search.Children),
+			optionalInt(listing.Search.PriceMin),
+			optionalInt(listing.Search.PriceMax),
+			listing.Search.RoomType,
+			listing.Search.PropertyType,
+			optionalInt(listing.Search.Window),
 		}
 		if err := writer.Write(record); err != nil {
 			return err
@@ -51,6 +62,14 @@ func (w *CSVWriter) WriteListings(listings []models.Listing) error {
 	return nil
 }
 
+// optionalInt leaves search filters that were not set, and the window outside a sweep, empty
+func optionalInt(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
 func cleanPrice(price string) string {
 	price = strings.TrimSpace(price)
 	price = strings.ReplaceAll(price, "$", "")
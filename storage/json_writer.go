@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/emon51/rental-scraper/models"
+)
+
+type JSONWriter struct {
+	filename string
+}
+
+func NewJSONWriter(filename string) *JSONWriter {
+	return &JSONWriter{filename: filename}
+}
+
+// WriteListings writes listings as an indented JSON array
+func (w *JSONWriter) WriteListings(listings []models.Listing) error {
+	file, err := os.Create(w.filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+
+	if listings == nil {
+		listings = []models.Listing{}
+	}
+
+	return encoder.Encode(listings)
+}
@@ -3,6 +3,7 @@ package storage
 import (
 	"database/sql"
 	"fmt"
+	"time"
 
 	_ "github.com/lib/pq"
 	"github.com/emon51/rental-scraper/models"
@@ -43,11 +44,38 @@ func (w *PostgresWriter) CreateTable() error {
 		price NUMERIC(10, 2),
 		location VARCHAR(255),
 		rating NUMERIC(3, 2),
-		url TEXT UNIQUE NOT NULL,
+		url TEXT NOT NULL,
 		description TEXT,
+		check_in DATE,
+		check_out DATE,
+		adults INTEGER,
+		children INTEGER,
+		price_min INTEGER,
+		price_max INTEGER,
+		room_type VARCHAR(50),
+		property_type VARCHAR(50),
+		sweep_window INTEGER,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 	);
-	
+
+	-- Search parameter columns for tables created before they existed
+	ALTER TABLE listings
+		ADD COLUMN IF NOT EXISTS check_in DATE,
+		ADD COLUMN IF NOT EXISTS check_out DATE,
+		ADD COLUMN IF NOT EXISTS adults INTEGER,
+		ADD COLUMN IF NOT EXISTS children INTEGER,
+		ADD COLUMN IF NOT EXISTS price_min INTEGER,
+		ADD COLUMN IF NOT EXISTS price_max INTEGER,
+		ADD COLUMN IF NOT EXISTS room_type VARCHAR(50),
+		ADD COLUMN IF NOT EXISTS property_type VARCHAR(50),
+		ADD COLUMN IF NOT EXISTS sweep_window INTEGER;
+
+	-- One row per listing and stay searched: a date sweep finds the same URL
+	-- in every window. Replaces the URL-only key of older tables.
+	ALTER TABLE listings DROP CONSTRAINT IF EXISTS listings_url_key;
+	CREATE UNIQUE INDEX IF NOT EXISTS listings_search_key
+		ON listings (url, check_in, check_out, sweep_window) NULLS NOT DISTINCT;
+
 	-- Indexes on important fields for query performance
 	CREATE INDEX IF NOT EXISTS idx_listings_price ON listings(price);
 	CREATE INDEX IF NOT EXISTS idx_listings_location ON listings(location);
@@ -63,7 +91,9 @@ func (w *PostgresWriter) CreateTable() error {
 	return nil
 }
 
-// InsertListings uses parameterized queries to prevent SQL injection
+// InsertListings uses parameterized queries to prevent SQL injection.
+// A listing already stored for the same stay is updated with the latest
+// price, rating and search parameters.
 func (w *PostgresWriter) InsertListings(listings []models.Listing) error {
 	if len(listings) == 0 {
 		return nil
@@ -78,9 +108,22 @@ func (w *PostgresWriter) InsertListings(listings []models.Listing) error {
 
 	// Parameterized query - prevents SQL injection
 	stmt, err := tx.Prepare(`
-		INSERT INTO listings (platform, title, price, location, rating, url, description)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		ON CONFLICT (url) DO NOTHING
+		INSERT INTO listings (platform, title, price, location, rating, url, description,
+			check_in, check_out, adults, children, price_min, price_max, room_type, property_type, sweep_window)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		ON CONFLICT (url, check_in, check_out, sweep_window) DO UPDATE SET
+			platform = EXCLUDED.platform,
+			title = EXCLUDED.title,
+			price = EXCLUDED.price,
+			location = EXCLUDED.location,
+			rating = EXCLUDED.rating,
+			description = EXCLUDED.description,
+			adults = EXCLUDED.adults,
+			children = EXCLUDED.children,
+			price_min = EXCLUDED.price_min,
+			price_max = EXCLUDED.price_max,
+			room_type = EXCLUDED.room_type,
+			property_type = EXCLUDED.property_type
 	`)
 	if err != nil {
 		return fmt.Errorf("failed to prepare statement: %w", err)
@@ -114,6 +157,15 @@ func (w *PostgresWriter) InsertListings(listings []models.Listing) error {
 			rating,
 			listing.URL,
 			listing.Description,
+			nullString(listing.Search.CheckIn),
+			nullString(listing.Search.CheckOut),
+			nullInt(listing.Search.Adults),
+			nullInt(listing.Search.Children),
+			nullInt(listing.Search.PriceMin),
+			nullInt(listing.Search.PriceMax),
+			nullString(listing.Search.RoomType),
+			nullString(listing.Search.PropertyType),
+			nullInt(listing.Search.Window),
 		)
 		if err != nil {
 			return fmt.Errorf("failed to insert listing: %w", err)
@@ -131,7 +183,8 @@ func (w *PostgresWriter) InsertListings(listings []models.Listing) error {
 // GetAllListings retrieves all listings - uses parameterized query
 func (w *PostgresWriter) GetAllListings() ([]models.Listing, error) {
 	query := `
-		SELECT platform, title, price, location, rating, url, description
+		SELECT platform, title, price, location, rating, url, description,
+			check_in, check_out, adults, children, price_min, price_max, room_type, property_type, sweep_window
 		FROM listings
 		ORDER BY created_at DESC
 	`
@@ -147,6 +200,9 @@ func (w *PostgresWriter) GetAllListings() ([]models.Listing, error) {
 	for rows.Next() {
 		var listing models.Listing
 		var price, rating sql.NullFloat64
+		var checkIn, checkOut sql.NullTime
+		var adults, children, priceMin, priceMax, window sql.NullInt64
+		var roomType, propertyType sql.NullString
 
 		err := rows.Scan(
 			&listing.Platform,
@@ -156,6 +212,15 @@ func (w *PostgresWriter) GetAllListings() ([]models.Listing, error) {
 			&rating,
 			&listing.URL,
 			&listing.Description,
+			&checkIn,
+			&checkOut,
+			&adults,
+			&children,
+			&priceMin,
+			&priceMax,
+			&roomType,
+			&propertyType,
+			&window,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
@@ -169,6 +234,20 @@ func (w *PostgresWriter) GetAllListings() ([]models.Listing, error) {
 			listing.Rating = fmt.Sprintf("%.2f", rating.Float64)
 		}
 
+		if checkIn.Valid {
+			listing.Search.CheckIn = checkIn.Time.Format(time.DateOnly)
+		}
+		if checkOut.Valid {
+			listing.Search.CheckOut = checkOut.Time.Format(time.DateOnly)
+		}
+		listing.Search.Adults = int(adults.Int64)
+		listing.Search.Children = int(children.Int64)
+		listing.Search.PriceMin = int(priceMin.Int64)
+		listing.Search.PriceMax = int(priceMax.Int64)
+		listing.Search.RoomType = roomType.String
+		listing.Search.PropertyType = propertyType.String
+		listing.Search.Window = int(window.Int64)
+
 		listings = append(listings, listing)
 	}
 
@@ -179,6 +258,22 @@ func (w *PostgresWriter) GetAllListings() ([]models.Listing, error) {
 	return listings, nil
 }
 
+// nullString stores an unset search filter as NULL
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// nullInt stores an unset search filter as NULL
+func nullInt(n int) *int {
+	if n == 0 {
+		return nil
+	}
+	return &n
+}
+
 func parsePrice(price string) float64 {
 	var p float64
 	fmt.Sscanf(price, "%f", &p)
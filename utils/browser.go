@@ -1,25 +1,16 @@
 package utils
 
 import (
-	"context"
-	"time"
-
 	"github.com/chromedp/chromedp"
 	"github.com/emon51/rental-scraper/config"
 )
 
-// CreateBrowserContext creates and configures the browser context
-func CreateBrowserContext(cfg *config.Config) context.Context {
-	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+// browserOptions returns the Chrome launch options for the configured browser
+func browserOptions(cfg *config.Config) []chromedp.ExecAllocatorOption {
+	return append(chromedp.DefaultExecAllocatorOptions[:],
 		chromedp.Flag("headless", cfg.Headless),
 		chromedp.Flag("disable-gpu", false),
 		chromedp.Flag("no-sandbox", true),
-		chromedp.UserAgent("Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
+		chromedp.UserAgent(config.DefaultUserAgent),
 	)
-
-	allocCtx, _ := chromedp.NewExecAllocator(context.Background(), opts...)
-	ctx, _ := chromedp.NewContext(allocCtx)
-	ctx, _ = context.WithTimeout(ctx, time.Duration(cfg.PageTimeout)*time.Second)
-
-	return ctx
-}
\ No newline at end of file
+}
@@ -0,0 +1,345 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/chromedp/cdproto/inspector"
+	"github.com/chromedp/cdproto/target"
+	"github.com/chromedp/chromedp"
+	"github.com/emon51/rental-scraper/config"
+)
+
+// ErrPoolClosed is returned by Acquire once the pool has been closed
+var ErrPoolClosed = errors.New("browser pool is closed")
+
+// BrowserPool owns one Chrome process and hands out its tabs.
+// Tabs are reused between workers, replaced after a set number of navigations
+// or when they crash, and everything is shut down by Close. Tabs for a proxy
+// live in a browser context of their own that routes through it.
+type BrowserPool struct {
+	allocCtx      context.Context
+	cancelAlloc   context.CancelFunc
+	browserCtx    context.Context
+	cancelBrowser context.CancelFunc
+
+	description  string
+	recycleAfter int
+	blocker      *RequestBlocker
+	slots        chan struct{} // bounds the number of open tabs
+
+	mu     sync.Mutex
+	idle   map[string][]*Tab // by proxy name, "" for direct
+	open   map[*Tab]struct{}
+	closed bool
+
+	contextsMu sync.Mutex
+	contexts   map[string]*proxyContext // by proxy name
+}
+
+// proxyContext is a browser context whose traffic goes through one proxy
+type proxyContext struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// Tab is a browser tab leased from a BrowserPool
+type Tab struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	proxy string // Name of the proxy the tab's browser context uses
+
+	mu          sync.Mutex
+	navigations int
+	broken      bool
+	profile     *config.ProfileConfig // Last profile applied, nil until ApplyProfile runs
+}
+
+type tabContextKey struct{}
+
+// NewBrowserPool launches a local browser, or connects to the remote one set in
+// browser.remote_url after checking it is healthy. Close must be called.
+func NewBrowserPool(cfg *config.Config) (*BrowserPool, error) {
+	var allocCtx context.Context
+	var cancelAlloc context.CancelFunc
+	description := "local Chrome"
+
+	if cfg.Browser.RemoteURL != "" {
+		info, err := CheckRemoteChrome(context.Background(), cfg.Browser.RemoteURL)
+		if err != nil {
+			return nil, err
+		}
+		allocCtx, cancelAlloc = chromedp.NewRemoteAllocator(context.Background(), info.WebSocketDebuggerURL, chromedp.NoModifyURL)
+		description = fmt.Sprintf("remote %s at %s", info.Browser, cfg.Browser.RemoteURL)
+	} else {
+		allocCtx, cancelAlloc = chromedp.NewExecAllocator(context.Background(), browserOptions(cfg)...)
+	}
+
+	browserCtx, cancelBrowser := chromedp.NewContext(allocCtx)
+
+	// Start the browser now so every tab shares this process
+	if err := chromedp.Run(browserCtx); err != nil {
+		cancelBrowser()
+		cancelAlloc()
+		return nil, fmt.Errorf("failed to start %s: %w", description, err)
+	}
+
+	return &BrowserPool{
+		description:   description,
+		blocker:       NewRequestBlocker(cfg.Blocking),
+		allocCtx:      allocCtx,
+		cancelAlloc:   cancelAlloc,
+		browserCtx:    browserCtx,
+		cancelBrowser: cancelBrowser,
+		recycleAfter:  cfg.Browser.RecycleAfter,
+		slots:         make(chan struct{}, cfg.Browser.EffectiveMaxTabs(cfg)),
+		idle:          make(map[string][]*Tab),
+		open:          make(map[*Tab]struct{}),
+		contexts:      make(map[string]*proxyContext),
+	}, nil
+}
+
+// String describes the browser behind the pool
+func (p *BrowserPool) String() string {
+	return p.description
+}
+
+// BlockedRequests returns the requests blocked so far in every tab, by resource type
+func (p *BrowserPool) BlockedRequests() map[string]int64 {
+	return p.blocker.Counts()
+}
+
+// Acquire returns an idle tab for proxy, or opens a new one, waiting while the
+// pool is full. A nil proxy connects directly.
+func (p *BrowserPool) Acquire(ctx context.Context, proxy *config.ProxyConfig) (*Tab, error) {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, context.Cause(ctx)
+	}
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		<-p.slots
+		return nil, ErrPoolClosed
+	}
+	key := proxyName(proxy)
+	if idle := p.idle[key]; len(idle) > 0 {
+		tab := idle[len(idle)-1]
+		p.idle[key] = idle[:len(idle)-1]
+		p.mu.Unlock()
+		return tab, nil
+	}
+	evicted := p.evictIdleLocked()
+	p.mu.Unlock()
+
+	if evicted != nil {
+		evicted.close()
+	}
+
+	tab, err := p.openTab(proxy)
+	if err != nil {
+		<-p.slots
+		return nil, err
+	}
+	return tab, nil
+}
+
+// Release returns a tab to the pool, closing it if it is worn out or broken
+func (p *BrowserPool) Release(tab *Tab) {
+	defer func() { <-p.slots }()
+
+	tab.mu.Lock()
+	retire := tab.broken || tab.ctx.Err() != nil ||
+		(p.recycleAfter > 0 && tab.navigations >= p.recycleAfter)
+	tab.mu.Unlock()
+
+	p.mu.Lock()
+	if retire || p.closed {
+		delete(p.open, tab)
+		p.mu.Unlock()
+		tab.close()
+		return
+	}
+	p.idle[tab.proxy] = append(p.idle[tab.proxy], tab)
+	p.mu.Unlock()
+}
+
+// evictIdleLocked makes room for a tab when the open tabs, counting idle tabs
+// kept for other proxies, have reached the pool size. The caller closes the
+// returned tab after unlocking.
+func (p *BrowserPool) evictIdleLocked() *Tab {
+	if len(p.open) < cap(p.slots) {
+		return nil
+	}
+	for key, idle := range p.idle {
+		if len(idle) == 0 {
+			continue
+		}
+		tab := idle[0]
+		p.idle[key] = idle[1:]
+		delete(p.open, tab)
+		return tab
+	}
+	return nil
+}
+
+// Close closes every tab and stops the browser. It is safe to call more than once.
+func (p *BrowserPool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	tabs := make([]*Tab, 0, len(p.open))
+	for tab := range p.open {
+		tabs = append(tabs, tab)
+	}
+	p.open = nil
+	p.idle = nil
+	p.mu.Unlock()
+
+	for _, tab := range tabs {
+		tab.close()
+	}
+
+	p.contextsMu.Lock()
+	for _, pc := range p.contexts {
+		pc.cancel()
+	}
+	p.contexts = nil
+	p.contextsMu.Unlock()
+
+	// Cancel closes a local Chrome gracefully and waits for the process to
+	// exit; for a remote browser it only closes our targets
+	err := chromedp.Cancel(p.browserCtx)
+	p.cancelBrowser()
+	p.cancelAlloc()
+
+	if err != nil && !errors.Is(err, context.Canceled) {
+		return fmt.Errorf("failed to stop browser: %w", err)
+	}
+	return nil
+}
+
+func (p *BrowserPool) openTab(proxy *config.ProxyConfig) (*Tab, error) {
+	parent, err := p.browserContext(proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	tabCtx, cancel := chromedp.NewContext(parent)
+	tab := &Tab{cancel: cancel, proxy: proxyName(proxy)}
+	tab.ctx = context.WithValue(tabCtx, tabContextKey{}, tab)
+
+	chromedp.ListenTarget(tabCtx, func(ev interface{}) {
+		if _, ok := ev.(*inspector.EventTargetCrashed); ok {
+			tab.MarkBroken()
+		}
+	})
+
+	if err := chromedp.Run(tabCtx); err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to open tab: %w", err)
+	}
+
+	if err := interceptRequests(tabCtx, p.blocker, proxy); err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to enable request interception: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		cancel()
+		return nil, ErrPoolClosed
+	}
+	p.open[tab] = struct{}{}
+
+	return tab, nil
+}
+
+// browserContext returns the chromedp context new tabs for proxy are opened
+// from, creating a browser context routed through the proxy on first use
+func (p *BrowserPool) browserContext(proxy *config.ProxyConfig) (context.Context, error) {
+	if proxy == nil {
+		return p.browserCtx, nil
+	}
+
+	p.contextsMu.Lock()
+	defer p.contextsMu.Unlock()
+
+	if p.contexts == nil {
+		return nil, ErrPoolClosed
+	}
+	if pc, ok := p.contexts[proxy.Name]; ok && pc.ctx.Err() == nil {
+		return pc.ctx, nil
+	}
+
+	ctx, cancel := chromedp.NewContext(p.browserCtx, chromedp.WithNewBrowserContext(
+		func(params *target.CreateBrowserContextParams) *target.CreateBrowserContextParams {
+			return params.WithProxyServer(proxy.URL)
+		},
+	))
+	if err := chromedp.Run(ctx); err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to create browser context for proxy %s: %w", proxy.Name, err)
+	}
+
+	p.contexts[proxy.Name] = &proxyContext{ctx: ctx, cancel: cancel}
+	return ctx, nil
+}
+
+func proxyName(proxy *config.ProxyConfig) string {
+	if proxy == nil {
+		return ""
+	}
+	return proxy.Name
+}
+
+// TabFromContext returns the pooled tab a context belongs to, if any
+func TabFromContext(ctx context.Context) (*Tab, bool) {
+	tab, ok := ctx.Value(tabContextKey{}).(*Tab)
+	return tab, ok
+}
+
+// Context returns the chromedp context for the tab
+func (t *Tab) Context() context.Context {
+	return t.ctx
+}
+
+// CountNavigation records a navigation for recycling
+func (t *Tab) CountNavigation() {
+	t.mu.Lock()
+	t.navigations++
+	t.mu.Unlock()
+}
+
+// MarkBroken retires the tab when it is next released
+func (t *Tab) MarkBroken() {
+	t.mu.Lock()
+	t.broken = true
+	t.mu.Unlock()
+}
+
+func (t *Tab) close() {
+	// Cancelling a tab context closes its target and waits for it
+	t.cancel()
+}
+
+func (t *Tab) hasProfile(p config.ProfileConfig) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.profile != nil && *t.profile == p
+}
+
+func (t *Tab) setProfile(p config.ProfileConfig) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.profile = &p
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"context"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/emon51/rental-scraper/config"
+)
+
+// interceptRequests pauses a tab's requests through the fetch domain to fail
+// the ones the blocker matches and to answer proxy authentication challenges.
+// Without proxy credentials only requests the blocker matches are paused, so
+// everything else loads without a round trip.
+func interceptRequests(tabCtx context.Context, blocker *RequestBlocker, proxy *config.ProxyConfig) error {
+	auth := proxy != nil && proxy.Username != ""
+	if blocker == nil && !auth {
+		return nil
+	}
+
+	patterns := []*fetch.RequestPattern{{URLPattern: "*"}}
+	if !auth {
+		patterns = blocker.patterns
+	}
+
+	chromedp.ListenTarget(tabCtx, func(ev interface{}) {
+		switch e := ev.(type) {
+		case *fetch.EventRequestPaused:
+			// Commands cannot be sent from inside the listener
+			go sendToTab(tabCtx, func(ctx context.Context) error {
+				if blocker.blocks(e) {
+					blocker.count(e.ResourceType)
+					return fetch.FailRequest(e.RequestID, network.ErrorReasonBlockedByClient).Do(ctx)
+				}
+				return fetch.ContinueRequest(e.RequestID).Do(ctx)
+			})
+
+		case *fetch.EventAuthRequired:
+			go sendToTab(tabCtx, func(ctx context.Context) error {
+				response := &fetch.AuthChallengeResponse{Response: fetch.AuthChallengeResponseResponseDefault}
+				if auth && e.AuthChallenge.Source == fetch.AuthChallengeSourceProxy {
+					response = &fetch.AuthChallengeResponse{
+						Response: fetch.AuthChallengeResponseResponseProvideCredentials,
+						Username: proxy.Username,
+						Password: proxy.Password.Reveal(),
+					}
+				}
+				return fetch.ContinueWithAuth(e.RequestID, response).Do(ctx)
+			})
+		}
+	})
+
+	return chromedp.Run(tabCtx, fetch.Enable().WithPatterns(patterns).WithHandleAuthRequests(auth))
+}
+
+// sendToTab runs a command against the tab; errors mean the tab or request is gone
+func sendToTab(tabCtx context.Context, send func(ctx context.Context) error) {
+	c := chromedp.FromContext(tabCtx)
+	if c == nil || c.Target == nil {
+		return
+	}
+	_ = send(cdp.WithExecutor(tabCtx, c.Target))
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/chromedp"
+	"github.com/emon51/rental-scraper/config"
+)
+
+// ApplyProfile sets the tab's user agent, viewport, locale and timezone from
+// profile. A nil profile restores the defaults, since pooled tabs carry their
+// overrides over to the next lease. A pooled tab that already has the profile
+// is left alone.
+func ApplyProfile(tabCtx context.Context, profile *config.ProfileConfig) error {
+	p := config.ProfileConfig{}
+	if profile != nil {
+		p = *profile
+	}
+
+	tab, pooled := TabFromContext(tabCtx)
+	if pooled && tab.hasProfile(p) {
+		return nil
+	}
+
+	userAgent := p.UserAgent
+	if userAgent == "" {
+		userAgent = config.DefaultUserAgent
+	}
+	uaOverride := emulation.SetUserAgentOverride(userAgent)
+	if p.AcceptLanguage != "" {
+		uaOverride = uaOverride.WithAcceptLanguage(p.AcceptLanguage)
+	}
+
+	// Chrome refuses to replace a locale override, so clear it first
+	actions := []chromedp.Action{
+		uaOverride,
+		emulation.SetLocaleOverride(),
+		emulation.SetTimezoneOverride(p.Timezone),
+	}
+	if p.Locale != "" {
+		actions = append(actions, emulation.SetLocaleOverride().WithLocale(p.Locale))
+	}
+	if p.ViewportWidth > 0 && p.ViewportHeight > 0 {
+		actions = append(actions, emulation.SetDeviceMetricsOverride(int64(p.ViewportWidth), int64(p.ViewportHeight), 1, false))
+	} else {
+		actions = append(actions, emulation.ClearDeviceMetricsOverride())
+	}
+
+	if err := chromedp.Run(tabCtx, actions...); err != nil {
+		return fmt.Errorf("failed to apply browser profile %q: %w", p.Name, err)
+	}
+
+	if pooled {
+		tab.setProfile(p)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// remoteCheckTimeout bounds the health check of a remote browser
+const remoteCheckTimeout = 10 * time.Second
+
+// RemoteChromeInfo is the part of /json/version the scraper uses
+type RemoteChromeInfo struct {
+	Browser              string `json:"Browser"`
+	WebSocketDebuggerURL string `json:"webSocketDebuggerUrl"`
+}
+
+// CheckRemoteChrome verifies that a DevTools endpoint is reachable and returns
+// its browser WebSocket URL. remoteURL may be ws://host:port, http://host:port
+// or a full ws://host:port/devtools/browser/<id> URL.
+func CheckRemoteChrome(ctx context.Context, remoteURL string) (*RemoteChromeInfo, error) {
+	u, err := url.Parse(remoteURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid remote Chrome URL %q", remoteURL)
+	}
+
+	httpScheme := "http"
+	if u.Scheme == "wss" || u.Scheme == "https" {
+		httpScheme = "https"
+	}
+	versionURL := httpScheme + "://" + u.Host + "/json/version"
+
+	ctx, cancel := context.WithTimeout(ctx, remoteCheckTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, versionURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("remote Chrome at %s is unreachable: %w", u.Host, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("remote Chrome health check %s returned %s", versionURL, resp.Status)
+	}
+
+	var info RemoteChromeInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("remote Chrome health check %s: invalid response: %w", versionURL, err)
+	}
+	if info.WebSocketDebuggerURL == "" {
+		return nil, fmt.Errorf("remote Chrome at %s did not report a WebSocket URL", u.Host)
+	}
+
+	// An explicit browser URL wins; otherwise keep the reported path but use
+	// the host we were given, since Chrome reports the address it bound to
+	if u.Path != "" && u.Path != "/" && (u.Scheme == "ws" || u.Scheme == "wss") {
+		info.WebSocketDebuggerURL = remoteURL
+	} else if ws, err := url.Parse(info.WebSocketDebuggerURL); err == nil {
+		ws.Host = u.Host
+		info.WebSocketDebuggerURL = ws.String()
+	}
+
+	return &info, nil
+}
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/emon51/rental-scraper/config"
+)
+
+// blockedByPattern is the counter key for requests blocked by a URL pattern
+const blockedByPattern = "url pattern"
+
+// RequestBlocker fails requests for unwanted resource types and URL patterns
+// through the DevTools fetch domain, and counts what it blocked
+type RequestBlocker struct {
+	types       map[network.ResourceType]bool
+	urlPatterns []*regexp.Regexp
+	patterns    []*fetch.RequestPattern // What the fetch domain pauses when nothing else needs interception
+
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+// NewRequestBlocker builds a blocker from config, or returns nil when nothing is blocked
+func NewRequestBlocker(cfg config.BlockingConfig) *RequestBlocker {
+	if len(cfg.ResourceTypes) == 0 && len(cfg.URLPatterns) == 0 {
+		return nil
+	}
+
+	b := &RequestBlocker{
+		types:  make(map[network.ResourceType]bool),
+		counts: make(map[string]int64),
+	}
+	for _, name := range cfg.ResourceTypes {
+		rt := ResourceType(name)
+		b.types[rt] = true
+		b.patterns = append(b.patterns, &fetch.RequestPattern{URLPattern: "*", ResourceType: rt})
+	}
+	for _, pattern := range cfg.URLPatterns {
+		b.urlPatterns = append(b.urlPatterns, wildcardPattern(pattern))
+		b.patterns = append(b.patterns, &fetch.RequestPattern{URLPattern: pattern})
+	}
+	return b
+}
+
+// ResourceType maps a config name such as "image" to the DevTools resource type
+func ResourceType(name string) network.ResourceType {
+	for _, rt := range resourceTypes {
+		if strings.EqualFold(string(rt), name) {
+			return rt
+		}
+	}
+	return network.ResourceType(name)
+}
+
+// resourceTypes are the DevTools resource types that may be blocked
+var resourceTypes = []network.ResourceType{
+	network.ResourceTypeStylesheet,
+	network.ResourceTypeImage,
+	network.ResourceTypeMedia,
+	network.ResourceTypeFont,
+	network.ResourceTypeScript,
+	network.ResourceTypeTextTrack,
+	network.ResourceTypeXHR,
+	network.ResourceTypeFetch,
+	network.ResourceTypePrefetch,
+	network.ResourceTypeEventSource,
+	network.ResourceTypeWebSocket,
+	network.ResourceTypeManifest,
+	network.ResourceTypePing,
+	network.ResourceTypeOther,
+}
+
+// blocks reports whether a paused request should be failed
+func (b *RequestBlocker) blocks(e *fetch.EventRequestPaused) bool {
+	if b == nil {
+		return false
+	}
+	if b.types[e.ResourceType] {
+		return true
+	}
+	for _, pattern := range b.urlPatterns {
+		if pattern.MatchString(e.Request.URL) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *RequestBlocker) count(rt network.ResourceType) {
+	key := blockedByPattern
+	if b.types[rt] {
+		key = strings.ToLower(string(rt))
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.counts[key]++
+}
+
+// wildcardPattern compiles a fetch domain URL pattern, where '*' matches any
+// run of characters and '?' any single one
+func wildcardPattern(pattern string) *regexp.Regexp {
+	expr := strings.NewReplacer(`\*`, ".*", `\?`, ".").Replace(regexp.QuoteMeta(pattern))
+	return regexp.MustCompile("^" + expr + "$")
+}
+
+// Counts returns the number of blocked requests per resource type, with
+// requests blocked only by a URL pattern under "url pattern"
+func (b *RequestBlocker) Counts() map[string]int64 {
+	counts := make(map[string]int64)
+	if b == nil {
+		return counts
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for key, n := range b.counts {
+		counts[key] = n
+	}
+	return counts
+}